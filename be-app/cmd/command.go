@@ -0,0 +1,98 @@
+package main
+
+import (
+	"dynamocker/internal/config"
+	harpkg "dynamocker/internal/har"
+	mockapifilepkg "dynamocker/internal/mock-api-file"
+	openapipkg "dynamocker/internal/openapi"
+	postmanpkg "dynamocker/internal/postman"
+	"flag"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const usage = `usage:
+  dynamocker                                      start the server
+  dynamocker import openapi <file>                create the mock APIs described by an OpenAPI 3 document
+  dynamocker import har [options] <file>          create the mock APIs from the entries of an HTTP Archive
+  dynamocker import postman <file>                create the mock APIs from the examples of a Postman collection (v2.1)
+
+options:`
+
+// run the command passed on the command line instead of starting the
+// server. It returns the exit code
+func runCommand(args []string) int {
+	flags := flag.NewFlagSet("dynamocker", flag.ContinueOnError)
+	keepHost := flags.Bool("keep-host", false, "har: use the host as first segment of the urls")
+	keepHeaders := flags.Bool("keep-headers", false, "har: keep the headers of the responses")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	if len(args) < 2 || args[0] != "import" {
+		flags.Usage()
+		return 2
+	}
+	format := args[1]
+	if err := flags.Parse(args[2:]); err != nil || flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		log.Errorf("error while reading the file to be imported: %s", err)
+		return 1
+	}
+
+	// the mock APIs are written in the configured store, which must outlive
+	// the command
+	if config.GetStore() == mockapifilepkg.StoreMemory {
+		log.Errorf("the mock APIs can't be imported into the %s store, which is lost once the command ends: set DYNA_STORE to %s or %s", mockapifilepkg.StoreMemory, mockapifilepkg.StoreFolder, mockapifilepkg.StoreDatabase)
+		return 1
+	}
+	if err := mockapifilepkg.Init(); err != nil {
+		log.Errorf("error initiating mockapi: %s", err)
+		return 1
+	}
+
+	var res mockapifilepkg.ImportResult
+	switch format {
+	case "openapi":
+		doc, err := openapipkg.Parse(data)
+		if err != nil {
+			log.Errorf("error while parsing the OpenAPI document: %s", err)
+			return 1
+		}
+		res = mockapifilepkg.ImportMockApis(openapipkg.ToMockApis(doc))
+	case "har":
+		har, err := harpkg.Parse(data)
+		if err != nil {
+			log.Errorf("error while parsing the har file: %s", err)
+			return 1
+		}
+		opts := harpkg.Options{KeepHost: *keepHost, KeepHeaders: *keepHeaders}
+		res = mockapifilepkg.ImportMockApis(harpkg.ToMockApis(har, opts))
+	case "postman":
+		collection, err := postmanpkg.Parse(data)
+		if err != nil {
+			log.Errorf("error while parsing the Postman collection: %s", err)
+			return 1
+		}
+		res = mockapifilepkg.ImportMockApis(postmanpkg.ToMockApis(collection))
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	for _, name := range res.Imported {
+		log.Infof("imported mock api '%s'", name)
+	}
+	for _, reason := range res.Skipped {
+		log.Warnf("skipped: %s", reason)
+	}
+	return 0
+}
@@ -3,7 +3,8 @@ package main
 import (
 	"dynamocker/internal/config"
 	mockapipkg "dynamocker/internal/mock-api"
-	mockapifilepkg "dynamocker/internal/mock-api-file"
+	proxypkg "dynamocker/internal/proxy"
+	requestjournalpkg "dynamocker/internal/request-journal"
 	webserver "dynamocker/internal/web-server"
 	"os"
 	"os/signal"
@@ -17,6 +18,11 @@ import (
 // TODO: complete Tests
 
 func main() {
+	// commands run without starting the server
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	log.Info("Hello there, this is DynaMocker")
 
 	closeCh := make(chan bool)
@@ -30,16 +36,22 @@ func main() {
 	// read the customized values of the configuration from the env variables
 	config.ReadVars()
 
-	// init the mocked api management
+	// init the mocked api management, along with the store of the mock apis
 	if err := mockapipkg.Init(closeCh, &wg); err != nil {
 		log.Errorf("error initiating mockapi: %s", err)
 		panic("panic during mockapi initiations")
 	}
 
-	// init the mocked api file management
-	if err := mockapifilepkg.Init(); err != nil {
-		log.Errorf("error initiating mockapi: %s", err)
-		panic("panic during mockapi initiations")
+	// init the journal of the served requests
+	if err := requestjournalpkg.Init(); err != nil {
+		log.Errorf("error initiating the request journal: %s", err)
+		panic("panic during request journal initiations")
+	}
+
+	// init the pass-through proxy of the requests matching no mockApi
+	if err := proxypkg.Init(); err != nil {
+		log.Errorf("error initiating the pass-through proxy: %s", err)
+		panic("panic during pass-through proxy initiations")
 	}
 
 	ws, err := webserver.NewServer()
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var notSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slug turns a text (e.g. a path or the title of a document) into lowercase
+// letters and digits separated by '-', to name the imported MockApis
+func Slug(text string) string {
+	return strings.Trim(notSlugChars.ReplaceAllString(strings.ToLower(text), "-"), "-")
+}
+
+// headers describing how the body has been transferred (compressed, chunked),
+// no longer true once the body is stored decoded
+var transportHeaders = []string{"Content-Encoding", "Content-Length", "Transfer-Encoding"}
+
+// DelTransportHeaders removes the transport headers of a response whose body
+// is stored decoded, e.g. in a HAR file or in a Postman collection
+func DelTransportHeaders(headers http.Header) {
+	for _, header := range transportHeaders {
+		headers.Del(header)
+	}
+}
+
+// MockApisByUrl collects the imported MockApis, one per url serving the
+// responses of all its methods
+type MockApisByUrl map[string]*MockApi
+
+// SetResponse sets the response of the method of the MockApi serving the url,
+// which is created with the given name if missing. It returns false if the
+// method is not supported
+func (m MockApisByUrl) SetResponse(url string, name string, method string, response *MethodResponse) bool {
+	mockApi, found := m[url]
+	if !found {
+		mockApi = &MockApi{Name: name, URL: url}
+	}
+	if !mockApi.Responses.SetByMethod(method, response) {
+		return false
+	}
+	m[url] = mockApi
+	return true
+}
+
+// Sorted returns the MockApis sorted by url
+func (m MockApisByUrl) Sorted() []*MockApi {
+	urls := make([]string, 0, len(m))
+	for url := range m {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	mockApis := make([]*MockApi, 0, len(urls))
+	for _, url := range urls {
+		mockApis = append(mockApis, m[url])
+	}
+	return mockApis
+}
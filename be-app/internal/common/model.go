@@ -1,10 +1,25 @@
 package common
 
-const MAX_SIZE_MOCKAPI_LIST = 65535
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
 
 // Structure used to model the MockApi.
 type MockApi struct {
 
+	// identifier used by the management api. A random UUID is generated for
+	// the MockApis created through the api, unless chosen by the user; it
+	// derives from the name if missing in a file
+	ID string `json:"id,omitempty"`
+
 	// name of the file without the path and the json suffixs
 	Name string `json:"name" validate:"required"`
 
@@ -12,11 +27,512 @@ type MockApi struct {
 	URL string `json:"url" validate:"required"`
 
 	Responses Response `json:"responses" validate:"required"`
+
+	// delay applied to all the responses which don't define their own
+	Delay *Delay `json:"delay,omitempty"`
+
+	// faults randomly injected instead of the regular responses
+	Faults []Fault `json:"faults,omitempty" validate:"dive"`
+
+	// name of the scenario whose state drives the candidates of this MockApi.
+	// Several MockApis can share the same scenario
+	Scenario string `json:"scenario,omitempty"`
+}
+
+// UnmarshalJSON reports the field errors of the responses with their path
+// (e.g. 'responses.get.body')
+func (m *MockApi) UnmarshalJSON(data []byte) error {
+	// the alias type has no UnmarshalJSON, avoiding the recursion
+	type mockApiAlias MockApi
+	err := json.Unmarshal(data, (*mockApiAlias)(m))
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		return fieldErr.within("responses", "")
+	}
+	return err
+}
+
+// initial state of all the scenarios
+const ScenarioStateStarted = "Started"
+
+// Types of fault that can be injected
+const (
+	// the connection is closed with a TCP reset, no response is sent
+	FaultConnectionReset = "connection_reset"
+	// the connection is closed without sending any response
+	FaultEmptyReply = "empty_reply"
+	// the response declares the full Content-Length, but the connection is
+	// closed after sending half of the body
+	FaultTruncatedBody = "truncated_body"
+	// the response is complete, but its json body is not valid
+	FaultMalformedJson = "malformed_json"
+	// the response is replaced by a random 500, 502, 503 or 504 error
+	FaultRandom5xx = "random_5xx"
+)
+
+// Fault injected with the given probability (between 0 and 1). Faults are
+// evaluated in order, so the sum of the probabilities should not exceed 1.
+type Fault struct {
+	Type        string  `json:"type" validate:"required,oneof=connection_reset empty_reply truncated_body malformed_json random_5xx"`
+	Probability float64 `json:"probability" validate:"min=0,max=1"`
 }
 
+// Types of delay applied before serving a response
+const (
+	// always the same delay
+	DelayTypeFixed = "fixed"
+	// random delay uniformly distributed between min and max
+	DelayTypeUniform = "uniform"
+	// random delay following a lognormal distribution, defined by its median
+	// and by the standard deviation (sigma) of the underlying normal distribution
+	DelayTypeLognormal = "lognormal"
+)
+
+// Delay applied before serving a response. All durations are in milliseconds.
+type Delay struct {
+	Type         string  `json:"type" validate:"required,oneof=fixed uniform lognormal"`
+	Milliseconds int     `json:"milliseconds,omitempty" validate:"min=0"`
+	Min          int     `json:"min,omitempty" validate:"min=0"`
+	Max          int     `json:"max,omitempty" validate:"min=0,gtefield=Min"`
+	Median       int     `json:"median,omitempty" validate:"required_if=Type lognormal,min=0"`
+	Sigma        float64 `json:"sigma,omitempty" validate:"min=0"`
+}
+
+// Responses of the MockApi by http method. HEAD is served using the response
+// of GET, without body.
 type Response struct {
-	Get    *map[string]interface{} `json:"get,omitempty"`
-	Patch  *map[string]interface{} `json:"patch,omitempty"`
-	Post   *map[string]interface{} `json:"post,omitempty"`
-	Delete *map[string]interface{} `json:"delete,omitempty"`
+	Get     *MethodResponse `json:"get,omitempty"`
+	Patch   *MethodResponse `json:"patch,omitempty"`
+	Post    *MethodResponse `json:"post,omitempty"`
+	Put     *MethodResponse `json:"put,omitempty"`
+	Delete  *MethodResponse `json:"delete,omitempty"`
+	Options *MethodResponse `json:"options,omitempty"`
+}
+
+// json names of the methods of the Response, in the order they are decoded
+var responseMethods = []string{"get", "patch", "post", "put", "delete", "options"}
+
+// UnmarshalJSON decodes the response of each method, reporting the field
+// errors with the path of the method (e.g. 'get.body'). As for the other
+// structs, the names of the methods are matched ignoring the case
+func (r *Response) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return &FieldError{Rule: "type", Message: fmt.Sprintf("responses must be a json object: %s", err)}
+	}
+	*r = Response{}
+	fields := map[string]**MethodResponse{
+		"get":     &r.Get,
+		"patch":   &r.Patch,
+		"post":    &r.Post,
+		"put":     &r.Put,
+		"delete":  &r.Delete,
+		"options": &r.Options,
+	}
+	for _, method := range responseMethods {
+		for key, value := range raw {
+			if !strings.EqualFold(key, method) {
+				continue
+			}
+			if err := json.Unmarshal(value, fields[method]); err != nil {
+				var fieldErr *FieldError
+				if errors.As(err, &fieldErr) {
+					return fieldErr.within(method, "")
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ByMethod returns the defined responses indexed by http method
+func (r *Response) ByMethod() map[string]*MethodResponse {
+	responses := make(map[string]*MethodResponse)
+	for method, response := range map[string]*MethodResponse{
+		http.MethodGet:     r.Get,
+		http.MethodPatch:   r.Patch,
+		http.MethodPost:    r.Post,
+		http.MethodPut:     r.Put,
+		http.MethodDelete:  r.Delete,
+		http.MethodOptions: r.Options,
+	} {
+		if !response.IsEmpty() {
+			responses[method] = response
+		}
+	}
+	return responses
+}
+
+// SetByMethod sets the response of the given http method. It returns false
+// if the method is not supported, HEAD included
+func (r *Response) SetByMethod(method string, response *MethodResponse) bool {
+	switch method {
+	case http.MethodGet:
+		r.Get = response
+	case http.MethodPatch:
+		r.Patch = response
+	case http.MethodPost:
+		r.Post = response
+	case http.MethodPut:
+		r.Put = response
+	case http.MethodDelete:
+		r.Delete = response
+	case http.MethodOptions:
+		r.Options = response
+	default:
+		return false
+	}
+	return true
+}
+
+// Types of body that can be served by a MethodResponse
+const (
+	// the body is any json value: object, array, string, number or bool
+	BodyTypeJson = "json"
+	// the body is a string served as it is (plain text, xml, html, csv...)
+	BodyTypeText = "text"
+	// the body is a base64 string, decoded before being served (pdf, images...)
+	BodyTypeBase64 = "base64"
+)
+
+// Status, headers and body of a mocked response. Status defaults to 200 when
+// it is not set.
+type ResponseDef struct {
+	Status   int               `json:"status,omitempty" validate:"omitempty,min=100,max=599"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     interface{}       `json:"body,omitempty"`
+	BodyType string            `json:"bodyType,omitempty" validate:"omitempty,oneof=json text base64"`
+	// render the headers and the body as templates using the request data
+	Template bool `json:"template,omitempty"`
+	// delay applied before serving the response, overrides the one of the MockApi
+	Delay *Delay `json:"delay,omitempty"`
+	// state the scenario of the MockApi moves to once the response is served
+	NewState string `json:"newState,omitempty"`
+}
+
+// Responses served in order on successive calls. Once the sequence is over,
+// the last response is served again.
+type Sequence []ResponseDef
+
+// Response served for a single http method. The candidates are evaluated in
+// order and the first one matching the request is served. If none of them
+// matches, the default response is served.
+type MethodResponse struct {
+	// default response, or sequence of default responses
+	ResponseDef
+	Sequence Sequence `json:"sequence,omitempty" validate:"dive"`
+
+	Candidates []Candidate `json:"candidates,omitempty" validate:"dive"`
+}
+
+// Response served only if the request satisfies all the match conditions and
+// the scenario of the MockApi is in the required state (if set)
+type Candidate struct {
+	Match RequestMatcher `json:"match"`
+	State string         `json:"state,omitempty"`
+
+	ResponseDef
+	Sequence Sequence `json:"sequence,omitempty" validate:"dive"`
+}
+
+// Conditions on the incoming request. Query parameters, headers and cookies
+// are matched by name, path parameters by the name used in the mock url.
+type RequestMatcher struct {
+	PathParams map[string]ValueMatcher `json:"pathParams,omitempty"`
+	Query      map[string]ValueMatcher `json:"query,omitempty"`
+	Headers    map[string]ValueMatcher `json:"headers,omitempty"`
+	Cookies    map[string]ValueMatcher `json:"cookies,omitempty"`
+	Body       []BodyMatcher           `json:"body,omitempty"`
+}
+
+// Condition on a single value. An empty matcher requires the value to be
+// present. Numbers and booleans are compared using their json representation.
+type ValueMatcher struct {
+	EqualTo interface{} `json:"equalTo,omitempty"`
+	// regular expression that the value must match
+	Matches string `json:"matches,omitempty"`
+	// the value must be absent
+	Absent bool `json:"absent,omitempty"`
+}
+
+// Condition on the body of the request. The JsonPath (e.g. $.user.ids[0])
+// selects the field of a json body to be matched. If it is empty, the whole
+// body is matched as a string.
+type BodyMatcher struct {
+	JsonPath string `json:"jsonPath,omitempty"`
+
+	ValueMatcher
+}
+
+// FieldError is the error of a field of a MockApi found while unmarshaling
+// it, e.g. a base64 body which can't be decoded. The Field is the path of the
+// json field (e.g. 'responses.get.body') and the Rule names the failed check
+type FieldError struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// the error as seen from the parent field: the path of the parent is added to
+// the field and, if set, the context to the message
+func (e *FieldError) within(parent string, context string) *FieldError {
+	within := *e
+	within.Field = parent
+	if e.Field != "" {
+		within.Field = parent + "." + e.Field
+	}
+	if context != "" {
+		within.Message = context + ": " + e.Message
+	}
+	return &within
+}
+
+// keys accepted by the structured form of the MethodResponse. A json object
+// using any other key is considered a bare body (legacy format)
+var methodResponseKeys = map[string]bool{
+	"status":     true,
+	"headers":    true,
+	"body":       true,
+	"bodyType":   true,
+	"candidates": true,
+	"template":   true,
+	"delay":      true,
+	"newState":   true,
+	"sequence":   true,
+}
+
+// UnmarshalJSON accepts both the structured form
+//
+//	{"status": 201, "headers": {"Location": "/users/1"}, "body": {...}}
+//
+// and the legacy form, where the whole object is the body served with 200.
+// A legacy body whose keys and values all fit the structured form (e.g.
+// {"body": {...}}) is read as a structured response.
+// An empty object means that the method is not defined.
+func (m *MethodResponse) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return &FieldError{Rule: "type", Message: fmt.Sprintf("response must be a json object: %s", err)}
+	}
+	*m = MethodResponse{}
+	if len(raw) == 0 {
+		return nil
+	}
+
+	if isStructuredResponse(raw) {
+		// the alias type has no UnmarshalJSON, avoiding the recursion
+		type methodResponseAlias MethodResponse
+		var structured methodResponseAlias
+		err := json.Unmarshal(data, &structured)
+		if err == nil {
+			*m = MethodResponse(structured)
+			return m.check()
+		}
+		// if it can't be decoded as structured response, it's a legacy body
+		// which happens to use the same keys (e.g. {"status":"ok"})
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			return err
+		}
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return err
+	}
+	m.Body = body
+	return nil
+}
+
+// check the bodies and the regular expressions of the candidates
+func (m *MethodResponse) check() error {
+	if err := m.checkBody(); err != nil {
+		return err
+	}
+	if err := m.Sequence.checkBody(); err != nil {
+		return err
+	}
+	for i, candidate := range m.Candidates {
+		field, context := fmt.Sprintf("candidates[%d]", i), fmt.Sprintf("candidate %d", i)
+		if err := candidate.checkBody(); err != nil {
+			return err.within(field, context)
+		}
+		if err := candidate.Sequence.checkBody(); err != nil {
+			return err.within(field, context)
+		}
+		if err := candidate.Match.checkRegex(); err != nil {
+			return err.within(field, context)
+		}
+	}
+	return nil
+}
+
+func (rm *RequestMatcher) checkRegex() *FieldError {
+	for _, matchers := range []map[string]ValueMatcher{rm.PathParams, rm.Query, rm.Headers, rm.Cookies} {
+		for name, matcher := range matchers {
+			if _, err := regexp.Compile(matcher.Matches); err != nil {
+				return &FieldError{Field: "match", Rule: "regex", Message: fmt.Sprintf("invalid regex for '%s': %s", name, err)}
+			}
+		}
+	}
+	for _, matcher := range rm.Body {
+		if _, err := regexp.Compile(matcher.Matches); err != nil {
+			return &FieldError{Field: "match", Rule: "regex", Message: fmt.Sprintf("invalid regex for the body: %s", err)}
+		}
+	}
+	return nil
+}
+
+// text and base64 bodies must be strings, base64 ones must be decodable
+func (m *ResponseDef) checkBody() *FieldError {
+	if m.BodyType != BodyTypeText && m.BodyType != BodyTypeBase64 || m.Body == nil {
+		return nil
+	}
+	if _, ok := m.Body.(string); !ok {
+		return &FieldError{Field: "body", Rule: "bodyType", Message: fmt.Sprintf("body of type '%s' must be a string", m.BodyType)}
+	}
+	if _, err := m.BodyBytes(); err != nil {
+		return &FieldError{Field: "body", Rule: "base64", Message: err.Error()}
+	}
+	return nil
+}
+
+func (s Sequence) checkBody() *FieldError {
+	for i := range s {
+		if err := s[i].checkBody(); err != nil {
+			return err.within(fmt.Sprintf("sequence[%d]", i), fmt.Sprintf("sequence %d", i))
+		}
+	}
+	return nil
+}
+
+// BodyBytes returns the bytes to be written in the response
+func (m *ResponseDef) BodyBytes() ([]byte, error) {
+	if m.Body == nil {
+		return nil, nil
+	}
+	switch m.BodyType {
+	case BodyTypeText:
+		return []byte(fmt.Sprint(m.Body)), nil
+	case BodyTypeBase64:
+		decoded, err := base64.StdEncoding.DecodeString(fmt.Sprint(m.Body))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 body: %s", err)
+		}
+		return decoded, nil
+	default:
+		bytes, err := json.Marshal(m.Body)
+		if err != nil {
+			return nil, err
+		}
+		return append(bytes, '\n'), nil
+	}
+}
+
+// ContentType returns the Content-Type matching the type of the body. The
+// Content-Type set in the headers takes precedence
+func (m *ResponseDef) ContentType() string {
+	for key, value := range m.Headers {
+		if http.CanonicalHeaderKey(key) == "Content-Type" {
+			return value
+		}
+	}
+	switch m.BodyType {
+	case BodyTypeText:
+		return "text/plain; charset=utf-8"
+	case BodyTypeBase64:
+		return "application/octet-stream"
+	default:
+		return "application/json"
+	}
+}
+
+// headers of a response not worth saving in a ResponseDef: they are computed
+// again when the response is served
+var volatileHeaders = []string{
+	"Content-Length",
+	"Content-Type",
+	"Date",
+}
+
+// NewResponseDef converts a raw http response (e.g. recorded from an upstream
+// or imported from a HAR file) into a ResponseDef. Json bodies are stored as
+// json, other utf-8 bodies as text and the binary ones in base64
+func NewResponseDef(status int, headers http.Header, body []byte) ResponseDef {
+	def := ResponseDef{Status: status}
+
+	kept := headers.Clone()
+	for _, header := range volatileHeaders {
+		kept.Del(header)
+	}
+	if len(kept) > 0 {
+		def.Headers = make(map[string]string)
+		for key := range kept {
+			def.Headers[key] = kept.Get(key)
+		}
+	}
+	if len(body) == 0 {
+		return def
+	}
+
+	contentType := headers.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	var jsonBody interface{}
+	switch {
+	case strings.HasSuffix(mediaType, "json") && json.Unmarshal(body, &jsonBody) == nil:
+		def.Body = jsonBody
+		def.BodyType = BodyTypeJson
+		// the default Content-Type of the json bodies is not worth saving
+		if mediaType == "application/json" {
+			return def
+		}
+	case utf8.Valid(body):
+		def.Body = string(body)
+		def.BodyType = BodyTypeText
+	default:
+		def.Body = base64.StdEncoding.EncodeToString(body)
+		def.BodyType = BodyTypeBase64
+	}
+	// keep the original Content-Type of text and binary bodies, and the custom
+	// one of json bodies (e.g. application/problem+json)
+	if contentType != "" {
+		if def.Headers == nil {
+			def.Headers = make(map[string]string)
+		}
+		def.Headers["Content-Type"] = contentType
+	}
+	return def
+}
+
+// IsEmpty returns true if the response has no status, headers or body
+func (m *ResponseDef) IsEmpty() bool {
+	return m == nil || (m.Status == 0 && len(m.Headers) == 0 && m.Body == nil)
+}
+
+// IsEmpty returns true if neither a default response nor candidates are
+// defined, that is the method is not defined for the MockApi
+func (m *MethodResponse) IsEmpty() bool {
+	return m == nil || (m.ResponseDef.IsEmpty() && len(m.Sequence) == 0 && len(m.Candidates) == 0)
+}
+
+// StatusCode returns the status to be served, defaulting to 200
+func (m *ResponseDef) StatusCode() int {
+	if m.Status == 0 {
+		return 200
+	}
+	return m.Status
+}
+
+func isStructuredResponse(raw map[string]json.RawMessage) bool {
+	for key := range raw {
+		if !methodResponseKeys[key] {
+			return false
+		}
+	}
+	return true
 }
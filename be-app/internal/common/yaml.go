@@ -0,0 +1,44 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YamlToJson converts a yaml document into json, to be unmarshaled as the
+// json documents. Json is valid yaml, so json documents are accepted as well
+func YamlToJson(data []byte) ([]byte, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return json.Marshal(normalizeYaml(raw))
+}
+
+// yaml mappings with non-string keys (e.g. numbers in a body or the response
+// codes of an OpenAPI document) are decoded as map[interface{}]interface{},
+// which can't be marshaled into json
+func normalizeYaml(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeYaml(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			res[fmt.Sprint(key)] = normalizeYaml(item)
+		}
+		return res
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYaml(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -9,21 +9,51 @@ import (
 // List of the environment variables to be checked. The corresponding
 // value is the default value
 const (
-	logEnv                = "DYNA_LOG_LEVEL"
-	logEnvDefault         = "INFO"
-	portEnv               = "DYNA_SERVER_PORT"
-	portEnvDefault        = "8150"
-	folderEnv             = "DYNA_MOCK_API_FOLDER"
-	folderEnvDefault      = "/mocks/"
-	pollerIntervalEnv     = "POLLER_INTERVAL"
-	pollerIntervalDefault = "60" // seconds
+	logEnv                    = "DYNA_LOG_LEVEL"
+	logEnvDefault             = "INFO"
+	portEnv                   = "DYNA_SERVER_PORT"
+	portEnvDefault            = "8150"
+	folderEnv                 = "DYNA_MOCK_API_FOLDER"
+	folderEnvDefault          = "/mocks/"
+	pollerIntervalEnv         = "POLLER_INTERVAL"
+	pollerIntervalDefault     = "60" // seconds
+	journalSizeEnv            = "DYNA_JOURNAL_SIZE"
+	journalSizeDefault        = "1000" // requests
+	recordUpstreamEnv         = "DYNA_RECORD_UPSTREAM"
+	recordUpstreamDefault     = "" // recording disabled
+	proxyUpstreamEnv          = "DYNA_PROXY_UPSTREAM"
+	proxyUpstreamDefault      = "" // pass-through proxy disabled
+	proxyIncludeEnv           = "DYNA_PROXY_INCLUDE"
+	proxyIncludeDefault       = "" // all the urls
+	proxyExcludeEnv           = "DYNA_PROXY_EXCLUDE"
+	proxyExcludeDefault       = ""
+	proxySetHeadersEnv        = "DYNA_PROXY_SET_HEADERS"
+	proxySetHeadersDefault    = ""
+	proxyRemoveHeadersEnv     = "DYNA_PROXY_REMOVE_HEADERS"
+	proxyRemoveHeadersDefault = ""
+	storeEnv                  = "DYNA_STORE"
+	storeDefault              = "folder" // folder, memory or db
+	storeFileEnv              = "DYNA_STORE_FILE"
+	storeFileDefault          = "/mocks/dynamocker.db"
+	mockApiFormatEnv          = "DYNA_MOCK_API_FORMAT"
+	mockApiFormatDefault      = "json" // json or yaml
 )
 
 var envVarList map[string]string = map[string]string{
-	logEnv:            logEnvDefault,
-	portEnv:           portEnvDefault,
-	folderEnv:         folderEnvDefault,
-	pollerIntervalEnv: pollerIntervalDefault,
+	logEnv:                logEnvDefault,
+	portEnv:               portEnvDefault,
+	folderEnv:             folderEnvDefault,
+	pollerIntervalEnv:     pollerIntervalDefault,
+	journalSizeEnv:        journalSizeDefault,
+	recordUpstreamEnv:     recordUpstreamDefault,
+	proxyUpstreamEnv:      proxyUpstreamDefault,
+	proxyIncludeEnv:       proxyIncludeDefault,
+	proxyExcludeEnv:       proxyExcludeDefault,
+	proxySetHeadersEnv:    proxySetHeadersDefault,
+	proxyRemoveHeadersEnv: proxyRemoveHeadersDefault,
+	storeEnv:              storeDefault,
+	storeFileEnv:          storeFileDefault,
+	mockApiFormatEnv:      mockApiFormatDefault,
 }
 
 // read all the env variables
@@ -72,3 +102,94 @@ func GetPollingInterval() string {
 		return pollerIntervalDefault
 	}
 }
+
+func GetJournalSize() string {
+	if val := os.Getenv(journalSizeEnv); val != "" {
+		return val
+	} else {
+		return journalSizeDefault
+	}
+}
+
+// base url of the upstream where the unmatched requests are forwarded to be
+// recorded. Empty if the recording is disabled
+func GetRecordUpstream() string {
+	if val := os.Getenv(recordUpstreamEnv); val != "" {
+		return val
+	} else {
+		return recordUpstreamDefault
+	}
+}
+
+// base url of the upstream where the requests matching no mockApi are passed
+// through. Empty if the pass-through proxy is disabled
+func GetProxyUpstream() string {
+	if val := os.Getenv(proxyUpstreamEnv); val != "" {
+		return val
+	} else {
+		return proxyUpstreamDefault
+	}
+}
+
+// comma separated url patterns passed through the proxy. Empty for all the urls
+func GetProxyInclude() string {
+	if val := os.Getenv(proxyIncludeEnv); val != "" {
+		return val
+	} else {
+		return proxyIncludeDefault
+	}
+}
+
+// comma separated url patterns never passed through the proxy
+func GetProxyExclude() string {
+	if val := os.Getenv(proxyExcludeEnv); val != "" {
+		return val
+	} else {
+		return proxyExcludeDefault
+	}
+}
+
+// comma separated 'Name:Value' headers set on the proxied requests
+func GetProxySetHeaders() string {
+	if val := os.Getenv(proxySetHeadersEnv); val != "" {
+		return val
+	} else {
+		return proxySetHeadersDefault
+	}
+}
+
+// comma separated names of the headers removed from the proxied requests
+func GetProxyRemoveHeaders() string {
+	if val := os.Getenv(proxyRemoveHeadersEnv); val != "" {
+		return val
+	} else {
+		return proxyRemoveHeadersDefault
+	}
+}
+
+// kind of store where the mock apis are persisted: folder, memory or db
+func GetStore() string {
+	if val := os.Getenv(storeEnv); val != "" {
+		return val
+	} else {
+		return storeDefault
+	}
+}
+
+// path of the database file, used by the db store
+func GetStoreFile() string {
+	if val := os.Getenv(storeFileEnv); val != "" {
+		return val
+	} else {
+		return storeFileDefault
+	}
+}
+
+// format of the mock api files written by the folder store: json or yaml
+func GetMockApiFormat() string {
+	if val := os.Getenv(mockApiFormatEnv); val != "" {
+		return val
+	} else {
+		return mockApiFormatDefault
+	}
+}
@@ -35,6 +35,30 @@ func TestConfig(t *testing.T) {
 			"test_folder",
 			folderEnv,
 		},
+		{
+			GetJournalSize,
+			journalSizeDefault,
+			"50",
+			journalSizeEnv,
+		},
+		{
+			GetStore,
+			storeDefault,
+			"memory",
+			storeEnv,
+		},
+		{
+			GetStoreFile,
+			storeFileDefault,
+			"/tmp/mocks.db",
+			storeFileEnv,
+		},
+		{
+			GetMockApiFormat,
+			mockApiFormatDefault,
+			"yaml",
+			mockApiFormatEnv,
+		},
 	}
 	for _, test := range getterTests {
 		test.Tester(t)
@@ -0,0 +1,154 @@
+package harpkg
+
+import (
+	"dynamocker/internal/common"
+	urlpatternpkg "dynamocker/internal/url-pattern"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Har is the subset of an HTTP Archive used to generate the mockApis
+type Har struct {
+	Log struct {
+		Entries []Entry `json:"entries"`
+	} `json:"log"`
+}
+
+type Entry struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+type Response struct {
+	Status  int         `json:"status"`
+	Headers []NameValue `json:"headers"`
+	Content Content     `json:"content"`
+}
+
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type Content struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	// "base64" if the text is encoded
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Options of the conversion into mockApis
+type Options struct {
+	// use the host as first segment of the url of the mockApis, so that the
+	// same path of different hosts is mocked separately
+	KeepHost bool
+	// keep the headers of the responses, otherwise only the Content-Type is kept
+	KeepHeaders bool
+}
+
+// Parse reads an HTTP Archive
+func Parse(data []byte) (*Har, error) {
+	var har Har
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("invalid har file: %s", err)
+	}
+	if har.Log.Entries == nil {
+		return nil, fmt.Errorf("invalid har file: no entries found")
+	}
+	return &har, nil
+}
+
+// ToMockApis generates a MockApi per url of the archive, serving the
+// recorded response of each method. The entries are deduplicated by url and
+// method, the last one is kept. The query string is ignored. The entries
+// which can't be mocked are skipped, the reason is returned
+func ToMockApis(har *Har, opts Options) ([]*common.MockApi, []string) {
+	mockApis := make(common.MockApisByUrl)
+	skipped := make([]string, 0)
+
+	for i, entry := range har.Log.Entries {
+		method := strings.ToUpper(entry.Request.Method)
+		path, err := mockUrl(entry.Request.URL, opts.KeepHost)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("entry %d (%s %s): %s", i, method, entry.Request.URL, err))
+			continue
+		}
+		if entry.Response.Status == 0 {
+			skipped = append(skipped, fmt.Sprintf("entry %d (%s %s): no response received", i, method, entry.Request.URL))
+			continue
+		}
+		def, err := responseDef(&entry.Response, opts.KeepHeaders)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("entry %d (%s %s): %s", i, method, entry.Request.URL, err))
+			continue
+		}
+
+		if !mockApis.SetResponse(path, "har-"+common.Slug(path), method, &common.MethodResponse{ResponseDef: *def}) {
+			skipped = append(skipped, fmt.Sprintf("entry %d (%s %s): method not supported", i, method, entry.Request.URL))
+		}
+	}
+	return mockApis.Sorted(), skipped
+}
+
+// url of the mockApi: the path of the request, prefixed by the host if kept
+func mockUrl(rawUrl string, keepHost bool) (string, error) {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %s", err)
+	}
+	path := strings.Trim(parsed.Path, "/")
+	if keepHost && parsed.Host != "" {
+		path = strings.Trim(parsed.Host+"/"+path, "/")
+	}
+	if path == "" {
+		return "", fmt.Errorf("the root url can't be mocked")
+	}
+	// segments like {id} would be read as parameters
+	if _, err := urlpatternpkg.Compile(path); err != nil || strings.Contains(path, "*") {
+		return "", fmt.Errorf("the url can't be mocked")
+	}
+	return path, nil
+}
+
+func responseDef(response *Response, keepHeaders bool) (*common.ResponseDef, error) {
+	headers := http.Header{}
+	for _, header := range response.Headers {
+		// http/2 pseudo headers, e.g. :status
+		if strings.HasPrefix(header.Name, ":") {
+			continue
+		}
+		headers.Add(header.Name, header.Value)
+	}
+	// the body is stored decoded in the har
+	common.DelTransportHeaders(headers)
+	if !keepHeaders {
+		contentType := headers.Get("Content-Type")
+		headers = http.Header{}
+		if contentType != "" {
+			headers.Set("Content-Type", contentType)
+		}
+	}
+	if headers.Get("Content-Type") == "" && response.Content.MimeType != "" {
+		headers.Set("Content-Type", response.Content.MimeType)
+	}
+
+	body := []byte(response.Content.Text)
+	if response.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(response.Content.Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 body: %s", err)
+		}
+		body = decoded
+	}
+	def := common.NewResponseDef(response.Status, headers, body)
+	return &def, nil
+}
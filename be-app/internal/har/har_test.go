@@ -0,0 +1,104 @@
+package harpkg
+
+import (
+	"dynamocker/internal/common"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const capture = `{
+  "log": {
+    "version": "1.2",
+    "entries": [
+      {
+        "request": {"method": "GET", "url": "https://api.example.com/v1/users?page=1"},
+        "response": {
+          "status": 200,
+          "headers": [
+            {"name": "content-type", "value": "application/json"},
+            {"name": "content-encoding", "value": "gzip"},
+            {"name": "x-request-id", "value": "abc"},
+            {"name": ":status", "value": "200"}
+          ],
+          "content": {"mimeType": "application/json", "text": "[{\"id\":1}]"}
+        }
+      },
+      {
+        "request": {"method": "GET", "url": "https://api.example.com/v1/users?page=2"},
+        "response": {
+          "status": 200,
+          "headers": [{"name": "content-type", "value": "application/json"}],
+          "content": {"mimeType": "application/json", "text": "[{\"id\":2}]"}
+        }
+      },
+      {
+        "request": {"method": "POST", "url": "https://api.example.com/v1/users"},
+        "response": {"status": 201, "headers": [{"name": "location", "value": "/v1/users/3"}], "content": {"mimeType": "", "text": ""}}
+      },
+      {
+        "request": {"method": "GET", "url": "https://cdn.example.com/v1/users"},
+        "response": {"status": 200, "headers": [], "content": {"mimeType": "image/png", "text": "iVBORw0KGgo=", "encoding": "base64"}}
+      },
+      {
+        "request": {"method": "GET", "url": "https://api.example.com/v1/blocked"},
+        "response": {"status": 0, "headers": [], "content": {}}
+      },
+      {
+        "request": {"method": "GET", "url": "https://api.example.com/"},
+        "response": {"status": 200, "headers": [], "content": {}}
+      }
+    ]
+  }
+}`
+
+func TestParse(t *testing.T) {
+	har, err := Parse([]byte(capture))
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(har.Log.Entries))
+
+	_, err = Parse([]byte(`{"log": {}}`))
+	assert.EqualError(t, err, "invalid har file: no entries found")
+	_, err = Parse([]byte(`not json`))
+	assert.NotNil(t, err)
+}
+
+func TestToMockApis(t *testing.T) {
+	har, err := Parse([]byte(capture))
+	assert.Nil(t, err)
+
+	// hosts stripped: the same path of both hosts is deduplicated, the last entry wins
+	mockApis, skipped := ToMockApis(har, Options{})
+	assert.Equal(t, 1, len(mockApis))
+	assert.Equal(t, []string{
+		"entry 4 (GET https://api.example.com/v1/blocked): no response received",
+		"entry 5 (GET https://api.example.com/): the root url can't be mocked",
+	}, skipped)
+	users := mockApis[0]
+	assert.Equal(t, "har-v1-users", users.Name)
+	assert.Equal(t, "v1/users", users.URL)
+	assert.Equal(t, common.BodyTypeBase64, users.Responses.Get.BodyType)
+	assert.Equal(t, "image/png", users.Responses.Get.ContentType())
+	// only the Content-Type is kept
+	assert.Nil(t, users.Responses.Post.Headers)
+	assert.Equal(t, http.StatusCreated, users.Responses.Post.Status)
+
+	// hosts kept
+	// hosts kept: the root url is mocked as the host
+	mockApis, _ = ToMockApis(har, Options{KeepHost: true, KeepHeaders: true})
+	assert.Equal(t, 3, len(mockApis))
+	assert.Equal(t, "api.example.com", mockApis[0].URL)
+	api := mockApis[1]
+	assert.Equal(t, "api.example.com/v1/users", api.URL)
+	assert.Equal(t, "har-api-example-com-v1-users", api.Name)
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": float64(2)}}, api.Responses.Get.Body)
+	assert.Nil(t, api.Responses.Get.Headers)
+	assert.Equal(t, map[string]string{"Location": "/v1/users/3"}, api.Responses.Post.Headers)
+	assert.Equal(t, "cdn.example.com/v1/users", mockApis[2].URL)
+
+	// the headers of the first entry, transport headers excluded
+	har.Log.Entries = har.Log.Entries[:1]
+	mockApis, _ = ToMockApis(har, Options{KeepHeaders: true})
+	assert.Equal(t, map[string]string{"X-Request-Id": "abc"}, mockApis[0].Responses.Get.Headers)
+}
@@ -0,0 +1,130 @@
+package mockapifilepkg
+
+import (
+	"dynamocker/internal/common"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucket of the database holding the mock apis, as json indexed by id
+var mockApiBucket = []byte("mockApis")
+
+// store saving the mock apis in a single embedded database file
+type databaseStore struct {
+	db *bolt.DB
+}
+
+func openDatabaseStore(path string) (*databaseStore, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error while opening the database %s: %s", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(mockApiBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error while initializing the database %s: %s", path, err)
+	}
+	log.Infof("mock apis stored in the database %s", path)
+	return &databaseStore{db: db}, nil
+}
+
+func (s *databaseStore) Ready() error {
+	return nil
+}
+
+func (s *databaseStore) Add(mockApi *common.MockApi) error {
+	data, err := json.Marshal(mockApi)
+	if err != nil {
+		return fmt.Errorf("mock api not stored. error while marshalling mockapi: %s", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(mockApiBucket)
+		if bucket.Get([]byte(mockApi.ID)) != nil {
+			return fmt.Errorf("a mock api with id '%s' is already stored", mockApi.ID)
+		}
+		if err := checkUnique(loadBucket(bucket), mockApi.ID, mockApi); err != nil {
+			return err
+		}
+		return bucket.Put([]byte(mockApi.ID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("mock api not stored: %s", err)
+	}
+	return nil
+}
+
+func (s *databaseStore) Modify(id string, mockApi *common.MockApi) error {
+	data, err := json.Marshal(mockApi)
+	if err != nil {
+		return fmt.Errorf("mock api not stored. error while marshalling mockapi: %s", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(mockApiBucket)
+		if bucket.Get([]byte(id)) == nil {
+			return fmt.Errorf("no mock api with id '%s' stored", id)
+		}
+		if err := checkUnique(loadBucket(bucket), id, mockApi); err != nil {
+			return err
+		}
+		return bucket.Put([]byte(id), data)
+	})
+}
+
+func (s *databaseStore) Remove(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(mockApiBucket)
+		if bucket.Get([]byte(id)) == nil {
+			return fmt.Errorf("no mock api with id '%s' stored", id)
+		}
+		return bucket.Delete([]byte(id))
+	})
+}
+
+func (s *databaseStore) RemoveAll() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(mockApiBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(mockApiBucket)
+		return err
+	})
+}
+
+func (s *databaseStore) Load() (map[string]*common.MockApi, error) {
+	var mockApis map[string]*common.MockApi
+	err := s.db.View(func(tx *bolt.Tx) error {
+		mockApis = loadBucket(tx.Bucket(mockApiBucket))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while reading the database: %s", err)
+	}
+	return mockApis, nil
+}
+
+// the mock apis of the bucket, the invalid ones are skipped
+func loadBucket(bucket *bolt.Bucket) map[string]*common.MockApi {
+	mockApis := make(map[string]*common.MockApi)
+	bucket.ForEach(func(key []byte, data []byte) error {
+		id := string(key)
+		var mockApi common.MockApi
+		if err := json.Unmarshal(data, &mockApi); err != nil {
+			log.Errorf("invalid mock api '%s' saved in the database: %s", id, err)
+			return nil
+		}
+		mockApis[id] = &mockApi
+		return nil
+	})
+	return mockApis
+}
+
+func (s *databaseStore) Close() error {
+	return s.db.Close()
+}
@@ -3,18 +3,16 @@ package mockapifilepkg
 import (
 	"dynamocker/internal/common"
 	"dynamocker/internal/config"
+	responsetemplatepkg "dynamocker/internal/response-template"
+	wiremockpkg "dynamocker/internal/wiremock"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"io/fs"
 	"os"
-	"strconv"
+	"path/filepath"
 	"strings"
 	"sync"
 
-	"math/rand"
-
 	"github.com/go-playground/validator/v10"
 	log "github.com/sirupsen/logrus"
 )
@@ -22,155 +20,188 @@ import (
 var mu sync.Mutex
 var folderPath = ""
 
+// store where the mock apis are persisted, the folder one unless configured otherwise
+var store Store = &folderStore{}
+
+// function notified of the changes made through the stores which are not
+// observed by the folder watcher. A nil mockApi means that it was removed
+var listener func(id string, mockApi *common.MockApi)
+
 func Init() error {
+	mu.Lock()
+	defer mu.Unlock()
+
 	folderPath = config.GetMockApiFolder()
+	if err := store.Close(); err != nil {
+		log.Errorf("error while closing the previous store: %s", err)
+	}
+	switch kind := config.GetStore(); kind {
+	case StoreFolder:
+		store = &folderStore{}
+	case StoreMemory:
+		store = newMemoryStore()
+	case StoreDatabase:
+		dbStore, err := openDatabaseStore(config.GetStoreFile())
+		if err != nil {
+			store = &folderStore{}
+			return err
+		}
+		store = dbStore
+	default:
+		store = &folderStore{}
+		return fmt.Errorf("unknown store '%s': expected one of %s, %s, %s", kind, StoreFolder, StoreMemory, StoreDatabase)
+	}
 	return nil
 }
 
-// it must act on the file. observer will do its job
+// SetListener sets the function notified of the changes of the stores which
+// are not observed by the folder watcher
+func SetListener(onChange func(id string, mockApi *common.MockApi)) {
+	mu.Lock()
+	defer mu.Unlock()
+	listener = onChange
+}
+
+// WatchedFolder returns true if the mock apis are stored in the folder, whose
+// changes have to be observed
+func WatchedFolder() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	_, isFolder := store.(*folderStore)
+	return isFolder
+}
+
+// notify the listener, unless the changes are detected by the folder watcher
+func notify(id string, mockApi *common.MockApi) {
+	if _, isFolder := store.(*folderStore); isFolder || listener == nil {
+		return
+	}
+	listener(id, mockApi)
+}
+
+// it must act on the store. observer will do its job
 func AddNewMockApiFile(body []byte) error {
 
 	mu.Lock()
 	defer mu.Unlock()
 
-	if folderPath == "" {
-		return fmt.Errorf("the mock API folder has not been set-up")
+	if err := store.Ready(); err != nil {
+		return err
 	}
 
-	// unmashal body
-	var mockApi common.MockApi
-	err := json.Unmarshal(body, &mockApi)
+	mockApi, err := parseMockApi(body)
 	if err != nil {
-		err := fmt.Errorf("error while unmarshaling body: %s", err)
 		return err
 	}
 
-	// validate body
-	vtor := validator.New(validator.WithRequiredStructEnabled())
-	vtorErr := vtor.Struct(mockApi)
-	if vtorErr != nil {
-		valErrs := vtorErr.(validator.ValidationErrors)
-		var valErrsCumulative error
-		for _, valErr := range valErrs {
-			valErrsCumulative = fmt.Errorf("%s\n%s", valErrsCumulative, valErr.Error())
-		}
-		if valErrsCumulative != nil {
-			err := fmt.Errorf("invalid mock api passed from post request: %s", valErrsCumulative)
-			return err
-		}
+	// the id is generated, unless chosen by the user
+	if mockApi.ID == "" {
+		mockApi.ID = responsetemplatepkg.NewUuid()
 	}
 
-	// check if a mockApi with the same name or URL already exists
-	// _, found := GetApiByName(mockApi.Name)
-	// if found {
-	// 	return fmt.Errorf("found another file using same name of the one to be added ('%s'). File %s not created", mockApi.Name, err)
-	// }
-	// _, found = GetApiByUrl(mockApi.Name)
-	// if found {
-	// 	return fmt.Errorf("found another file using same URL of the one to be added ('%s'). File %s not created", mockApi.URL, err)
-	// }
+	if err := store.Add(mockApi); err != nil {
+		return err
+	}
+	notify(mockApi.ID, mockApi)
 
-	// retrieve file path
-	filePath := folderPath + fmt.Sprint(generateUuid()) + ".json"
+	return nil
+}
 
-	// transform mockApi into []byte
-	bytes, err := json.Marshal(mockApi)
-	if err != nil {
-		return fmt.Errorf("file %s not created. error while marshalling modified mockapi: %s", filePath, err)
+// it must act on the store. observer will do its job
+func RemoveMockApiFile(id string) error {
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := store.Ready(); err != nil {
+		return err
 	}
 
-	// write mockapi
-	if err := os.WriteFile(filePath, bytes, fs.ModePerm); err != nil {
-		return fmt.Errorf("file %s not created: %s", filePath, err)
+	if err := store.Remove(id); err != nil {
+		return err
 	}
+	notify(id, nil)
 
 	return nil
 }
 
-// it must act on the file. observer will do its job
-func RemoveMockApiFile(uuid uint16) error {
+// it must act on the store. observer will do its job
+func RemoveAllMockApisFiles() error {
 
 	mu.Lock()
 	defer mu.Unlock()
 
-	if folderPath == "" {
-		return fmt.Errorf("the mock API folder has not been set-up")
+	if err := store.Ready(); err != nil {
+		return err
 	}
 
-	file, err := os.Stat(folderPath + fmt.Sprint(uuid) + ".json")
-
+	removed, err := store.Load()
 	if err != nil {
-		return fmt.Errorf("error while getting entries from the mock api folder: %s", err)
+		return err
 	}
-
-	if err = os.Remove(folderPath + file.Name()); err != nil {
-		return fmt.Errorf("file %s not removed: %s", file.Name(), err)
+	if err := store.RemoveAll(); err != nil {
+		return err
+	}
+	for id := range removed {
+		notify(id, nil)
 	}
 
 	return nil
+
 }
 
-// it must act on the file. observer will do its job
-func RemoveAllMockApisFiles() error {
+// it must act on the store. observer will do its job
+func ModifyMockApiFile(mockApiId string, newFile []byte) error {
 
 	mu.Lock()
 	defer mu.Unlock()
 
-	if folderPath == "" {
-		return fmt.Errorf("the mock API folder has not been set-up")
+	if err := store.Ready(); err != nil {
+		return err
 	}
 
-	var files []fs.DirEntry
-	var err error
-
-	if files, err = os.ReadDir(folderPath); err != nil {
-		return fmt.Errorf("error while getting entries from the mock api folder: %s", err)
+	mockApi, err := parseMockApi(newFile)
+	if err != nil {
+		return err
 	}
 
-	for _, file := range files {
-
-		if !strings.HasSuffix(file.Name(), ".json") {
-			continue
-		}
-
-		filePath := folderPath + file.Name()
-
-		if err = os.Remove(filePath); err != nil {
-			return fmt.Errorf("file %s not removed: %s", file.Name(), err)
-		}
+	// the mock api keeps its id
+	if mockApi.ID != "" && mockApi.ID != mockApiId {
+		return fmt.Errorf("the id of the mock api '%s' can't be changed into '%s'", mockApiId, mockApi.ID)
+	}
+	mockApi.ID = mockApiId
 
+	if err := store.Modify(mockApiId, mockApi); err != nil {
+		return err
 	}
+	notify(mockApiId, mockApi)
 
 	return nil
-
 }
 
-// it must act on the file. observer will do its job
-func ModifyMockApiFile(mockApiUuid uint16, newFile []byte) error {
+// loading the APIs from the store at startup
+// this function updates the list based on the entries loaded from the store
+func LoadAPIsFromFolder() (map[string]*common.MockApi, error) {
 
 	mu.Lock()
 	defer mu.Unlock()
 
-	if folderPath == "" {
-		return fmt.Errorf("the mock API folder has not been set-up")
+	if err := store.Ready(); err != nil {
+		return nil, err
 	}
 
-	file, err := os.Stat(folderPath + fmt.Sprint(mockApiUuid) + ".json")
+	return store.Load()
+}
 
-	if err != nil {
-		return fmt.Errorf("error while getting entries from the mock api folder: %s", err)
-	}
-
-	if err = os.Remove(folderPath + file.Name()); err != nil {
-		return fmt.Errorf("file %s not removed: %s", file.Name(), err)
-	}
+// unmarshal and check the body of a request adding or modifying a mock api
+func parseMockApi(body []byte) (*common.MockApi, error) {
 
 	// unmashal body
 	var mockApi common.MockApi
-	err = json.Unmarshal(newFile, &mockApi)
+	err := json.Unmarshal(body, &mockApi)
 	if err != nil {
 		err := fmt.Errorf("error while unmarshaling body: %s", err)
-		return err
+		return nil, err
 	}
 
 	// validate body
@@ -184,121 +215,164 @@ func ModifyMockApiFile(mockApiUuid uint16, newFile []byte) error {
 		}
 		if valErrsCumulative != nil {
 			err := fmt.Errorf("invalid mock api passed from post request: %s", valErrsCumulative)
-			return err
+			return nil, err
 		}
 	}
 
-	// retrieve file path
-	filePath := folderPath + fmt.Sprint(mockApiUuid) + ".json"
-
-	// transform mockApi into []byte
-	bytes, err := json.Marshal(mockApi)
-	if err != nil {
-		return fmt.Errorf("file %s not created. error while marshalling modified mockapi: %s", filePath, err)
-	}
-
-	// write mockapi
-	if err := os.WriteFile(filePath, bytes, fs.ModePerm); err != nil {
-		return fmt.Errorf("file %s not created: %s", filePath, err)
+	// check url pattern and matchers
+	if err := CheckMockApi(&mockApi); err != nil {
+		return nil, fmt.Errorf("invalid mock api passed from post request: %s", err)
 	}
 
-	return nil
+	return &mockApi, nil
 }
 
-// loading the APIs from the mock api folder at startup
-// this function updates the list based on the entried loaded from the folder
-func LoadAPIsFromFolder() (map[uint16]*common.MockApi, error) {
-
-	mu.Lock()
-	defer mu.Unlock()
-
-	mockApiList := make(map[uint16]*common.MockApi)
-
-	// get path from config package
-	if folderPath == "" {
-		return nil, fmt.Errorf("the mock API folder has not been set-up")
+// DecodeMockApi reads the content of a mock api file, either in the format of
+// dynamocker or as WireMock stub mappings, and checks it. The yaml files are
+// converted into json first. The body files of the WireMock mappings are read
+// from the '__files' folder, see wireMockFilesDir.
+func DecodeMockApi(pathToFile string, data []byte) (*common.MockApi, error) {
+	if isYaml(pathToFile) {
+		jsonData, err := common.YamlToJson(data)
+		if err != nil {
+			return nil, fmt.Errorf("error while converting the yaml into json: %s", err)
+		}
+		data = jsonData
 	}
+	return decodeJsonMockApi(pathToFile, data)
+}
 
-	files, err := os.ReadDir(folderPath)
+// DecodeMockApiFile reads the content of a mock api file, holding either a
+// single mock api or a list of them. The path of the file is relative to the
+// mock folder. The mock apis are returned by id, the ones missing it take the
+// one derived from their name. The invalid mock apis of a list are skipped
+func DecodeMockApiFile(folder string, relPath string, data []byte) (map[string]*common.MockApi, error) {
+	pathToFile := filepath.Join(folder, relPath)
+	list, isList, err := mockApiList(pathToFile, data)
 	if err != nil {
-		return nil, fmt.Errorf("error while getting entries from the mock api folder: %s", err)
+		return nil, err
 	}
 
-	for _, file := range files {
-
-		// select only *.json files
-		if !strings.HasSuffix(file.Name(), ".json") {
-			continue
-		}
-
-		pathToFile := folderPath + "/" + file.Name()
-
-		uuidString, found := strings.CutSuffix(file.Name(), ".json")
-		if !found {
-			err = fmt.Errorf("suffix '.json' not found")
+	if !isList {
+		mockApi, err := DecodeMockApi(pathToFile, data)
+		if err != nil {
 			return nil, err
 		}
-		mockApiUuid64, err := strconv.ParseUint(uuidString, 10, 16)
-		if err != nil {
-			err := fmt.Errorf("error while parsing uuid of the mockApi file '%s' into uint16", file.Name())
-			log.Error(err)
+		if err := resolveId(mockApi); err != nil {
+			return nil, err
 		}
-		uuid := uint16(mockApiUuid64)
+		return map[string]*common.MockApi{mockApi.ID: mockApi}, nil
+	}
 
-		// open file
-		jsonFile, err := os.Open(pathToFile)
-		if err != nil {
-			log.Errorf("error while opening the file %s: %s", pathToFile, err)
-			continue
+	mockApis := make(map[string]*common.MockApi, len(list))
+	for i, element := range list {
+		mockApi, err := decodeJsonMockApi(pathToFile, element)
+		if err == nil {
+			err = resolveId(mockApi)
 		}
-
-		// read content
-		byteValue, err := io.ReadAll(jsonFile)
 		if err != nil {
-			log.Errorf("error while reading the file %s: %s", pathToFile, err)
+			log.Errorf("mock api %d of the file %s skipped: %s", i, relPath, err)
 			continue
 		}
-
-		// unmarshal content
-		var mockApi common.MockApi
-		err = json.Unmarshal(byteValue, &mockApi)
-		if err != nil {
-			log.Errorf("error while unmarshaling the json file %s into the struct: %s", pathToFile, err)
+		if _, found := mockApis[mockApi.ID]; found {
+			log.Errorf("mock api %d of the file %s skipped: another mock api of the file has the id '%s'", i, relPath, mockApi.ID)
 			continue
 		}
+		mockApis[mockApi.ID] = mockApi
+	}
+	return mockApis, nil
+}
 
-		// validate content
-		vtor := validator.New(validator.WithRequiredStructEnabled())
-		err = vtor.Struct(mockApi)
+// decode a mock api, in json, and check it
+func decodeJsonMockApi(pathToFile string, data []byte) (*common.MockApi, error) {
+	var mockApi *common.MockApi
+	if wiremockpkg.IsMapping(data) {
+		mockApis, err := wireMockMockApis(pathToFile, data)
 		if err != nil {
-			log.Errorf("invalid mock api saved in the json file %s into the struct: %s", pathToFile, err)
-			continue
+			return nil, err
+		}
+		if len(mockApis) > 1 {
+			return nil, fmt.Errorf("invalid WireMock mapping: the mappings serve %d different urls", len(mockApis))
+		}
+		mockApi = mockApis[0]
+	} else {
+		mockApi = &common.MockApi{}
+		if err := json.Unmarshal(data, mockApi); err != nil {
+			return nil, fmt.Errorf("error while unmarshaling the json into the struct: %s", err)
 		}
+	}
 
-		// add to the map
-		mockApiList[uuid] = &mockApi
+	// validate content
+	vtor := validator.New(validator.WithRequiredStructEnabled())
+	if err := vtor.Struct(mockApi); err != nil {
+		return nil, err
+	}
 
+	// check url pattern and matchers
+	if err := CheckMockApi(mockApi); err != nil {
+		return nil, err
 	}
+	return mockApi, nil
+}
 
-	return mockApiList, nil
+// convert the WireMock mappings of a file into a mock api per url, named after
+// the file if the mappings have no name. The body files are read from the
+// '__files' folder, see wireMockFilesDir
+func wireMockMockApis(pathToFile string, data []byte) ([]*common.MockApi, error) {
+	mappings, err := wiremockpkg.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	fileName := filepath.Base(pathToFile)
+	name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	filesDir := wireMockFilesDir(pathToFile)
+	mockApis, err := wiremockpkg.ToMockApis(mappings, name, filesDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WireMock mapping: %s", err)
+	}
+	return mockApis, nil
 }
 
-// generate a random uuid
-func generateUuid() uint16 {
-	var tmp uint16
-	var counter uint16 = 0
-	for {
-		if counter > 100 {
-			log.Fatal("Reached maximum numbers of attempts in generating a random uuid")
+// folder of the body files of the WireMock mappings of the file. As in the
+// root folder of WireMock, it is the '__files' folder next to the 'mappings'
+// one holding the file, also in a subfolder of it. Out of a 'mappings' folder,
+// or if there is no '__files' folder next to it, it is the one next to the file
+func wireMockFilesDir(pathToFile string) string {
+	dir := filepath.Dir(pathToFile)
+	for mappingsDir := dir; ; mappingsDir = filepath.Dir(mappingsDir) {
+		if filepath.Base(mappingsDir) == "mappings" {
+			filesDir := filepath.Join(filepath.Dir(mappingsDir), "__files")
+			if info, err := os.Stat(filesDir); err == nil && info.IsDir() {
+				return filesDir
+			}
+			break
+		}
+		if filepath.Dir(mappingsDir) == mappingsDir {
+			break
 		}
-		tmp = uint16(rand.Intn(common.MAX_SIZE_MOCKAPI_LIST))
+	}
+	return filepath.Join(dir, "__files")
+}
 
-		_, err := os.Stat(folderPath + fmt.Sprint(tmp) + "json")
+// CheckMockApi performs the checks not covered by the validator: the id must
+// fit in a url, the url must be a valid pattern, the matchers of the
+// candidates and the templates must be well formed. It returns the first
+// error found
+func CheckMockApi(mockApi *common.MockApi) error {
+	if errs := checkMockApi(mockApi); len(errs) > 0 {
+		return errors.New(errs[0].Message)
+	}
+	return nil
+}
 
-		if errors.Is(err, fs.ErrNotExist) {
-			break
+func checkTemplates(response common.ResponseDef, sequence common.Sequence) error {
+	if err := responsetemplatepkg.Check(&response); err != nil {
+		return err
+	}
+	for i := range sequence {
+		if err := responsetemplatepkg.Check(&sequence[i]); err != nil {
+			return fmt.Errorf("sequence %d: %s", i, err)
 		}
-		counter++
 	}
-	return tmp
+	return nil
 }
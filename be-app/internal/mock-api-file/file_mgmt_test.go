@@ -7,7 +7,7 @@ import (
 	"io/fs"
 	"math/rand"
 	"os"
-	"strconv"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -42,10 +42,10 @@ func dummyMockApi(t *testing.T) common.MockApi {
 
 // write a dummy mock api file to the Temp folder. The temp folder
 // comes from os package
-func writeDummyMockApiFile(t *testing.T) (uint16, *os.File, common.MockApi) {
+func writeDummyMockApiFile(t *testing.T) (string, *os.File, common.MockApi) {
 	mockApi := dummyMockApi(t)
-	uuid := uint16(rand.Intn(1000))
-	filename := fmt.Sprintf("%d", uuid) + ".json"
+	id := fmt.Sprintf("dummy-mock-api-%d", rand.Intn(1000))
+	filename := id + ".json"
 	filePath := os.TempDir() + "/" + filename
 	file, err := os.Create(filePath)
 	if err != nil {
@@ -58,7 +58,8 @@ func writeDummyMockApiFile(t *testing.T) (uint16, *os.File, common.MockApi) {
 		file.Close()
 		t.Fatal("malformed string modification")
 	}
-	mockApi.Name = fmt.Sprintf("dummy-mock-api-%d", uuid)
+	mockApi.ID = id
+	mockApi.Name = id
 	data, err := json.Marshal(mockApi)
 	if err != nil {
 		file.Close()
@@ -68,7 +69,7 @@ func writeDummyMockApiFile(t *testing.T) (uint16, *os.File, common.MockApi) {
 		file.Close()
 		t.Fatalf("error while writing dummy mock api to file :%s", err)
 	}
-	return uuid, file, mockApi
+	return id, file, mockApi
 }
 
 func TestAddNewMockApiFile(t *testing.T) {
@@ -91,7 +92,7 @@ func TestAddNewMockApiFile(t *testing.T) {
 	// check the mockApi has been added
 	var files []fs.DirEntry
 	var jsonFilescounter = 0
-	var uuidString string
+	var fileName string
 	var found = false
 	if files, err = os.ReadDir(folderPath); err != nil {
 		t.Fatalf("error while getting entries from the mock api folder: %s", err)
@@ -102,13 +103,13 @@ func TestAddNewMockApiFile(t *testing.T) {
 			continue
 		}
 		jsonFilescounter++
-		uuidString, found = strings.CutSuffix(file.Name(), ".json")
+		fileName, found = file.Name(), true
 	}
 	assert.True(t, found)
 	assert.Equal(t, 1, jsonFilescounter, "this means that some other json file is present in the test folder, jeopardizing the test result")
 
 	defer func() {
-		filename := folderPath + uuidString + ".json"
+		filename := folderPath + fileName
 		_, err := os.Stat(filename)
 		if err == nil {
 			err = os.Remove(filename)
@@ -118,6 +119,47 @@ func TestAddNewMockApiFile(t *testing.T) {
 		}
 	}()
 
+	// the file is named after the mock api, which gets a random id
+	assert.Equal(t, api.Name+".json", fileName)
+	mockApis, err := LoadAPIsFromFolder()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mockApis))
+	for id := range mockApis {
+		assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", id)
+	}
+
+	// the name and the url must be unique
+	api.URL = api.URL + "-bis"
+	bytes, err = json.Marshal(api)
+	if err != nil {
+		t.Fatalf("error while marshaling dummy mock api :%s", err)
+	}
+	assert.EqualError(t, AddNewMockApiFile(bytes), "found another mockApi with the same name '"+api.Name+"'")
+	sameUrl := dummyMockApi(t)
+	sameUrl.Name = api.Name + "-ter"
+	bytes, err = json.Marshal(sameUrl)
+	if err != nil {
+		t.Fatalf("error while marshaling dummy mock api :%s", err)
+	}
+	assert.EqualError(t, AddNewMockApiFile(bytes), "found another mockApi with the same URL '"+sameUrl.URL+"'")
+
+	// the id chosen by the user must be unique
+	api.ID = "chosen-id"
+	api.Name = api.Name + "-bis"
+	bytes, err = json.Marshal(api)
+	if err != nil {
+		t.Fatalf("error while marshaling dummy mock api :%s", err)
+	}
+	assert.Nil(t, AddNewMockApiFile(bytes))
+	defer os.Remove(folderPath + api.Name + ".json")
+	assert.EqualError(t, AddNewMockApiFile(bytes), "a mock api with id 'chosen-id' is already stored in the file "+api.Name+".json")
+	api.ID = "chosen id"
+	bytes, err = json.Marshal(api)
+	if err != nil {
+		t.Fatalf("error while marshaling dummy mock api :%s", err)
+	}
+	assert.EqualError(t, AddNewMockApiFile(bytes), "invalid mock api passed from post request: invalid id 'chosen id': only letters, digits, '.', '_', '~' and '-' are allowed")
+
 	// add invalid json
 	assert.EqualError(t, AddNewMockApiFile([]byte("invalid json")), "error while unmarshaling body: invalid character 'i' looking for beginning of value")
 
@@ -154,25 +196,25 @@ func TestRemoveMockApiFile(t *testing.T) {
 	reset()
 
 	// remove file while folderpath == ""
-	assert.EqualError(t, RemoveMockApiFile(0), "the mock API folder has not been set-up")
+	assert.EqualError(t, RemoveMockApiFile("dummy"), "the mock API folder has not been set-up")
 
 	folderPath = os.TempDir() + "/"
 
 	// add mock api
-	uuid, dummyMockApiFile, _ := writeDummyMockApiFile(t)
+	id, dummyMockApiFile, _ := writeDummyMockApiFile(t)
 	defer func() {
 		dummyMockApiFile.Close()
-		os.Remove(os.TempDir() + "/" + fmt.Sprint(uuid) + ".json")
+		os.Remove(os.TempDir() + "/" + id + ".json")
 	}()
 
 	// check that the api has been loaded
 	mockApis, err := LoadAPIsFromFolder()
 	assert.Nil(t, err, "the function should return no error")
 	assert.Equal(t, 1, len(mockApis))
-	_, found := mockApis[uuid]
+	_, found := mockApis[id]
 	assert.True(t, found)
 
-	err = RemoveMockApiFile(uuid)
+	err = RemoveMockApiFile(id)
 	assert.Nil(t, err)
 
 	// check that the api has been removed
@@ -222,7 +264,7 @@ func TestModifyMockApiFile(t *testing.T) {
 	// check the mockApi has been added
 	var files []fs.DirEntry
 	var jsonFilescounter = 0
-	var uuidString string
+	var fileName string
 	var found = false
 	if files, err = os.ReadDir(folderPath); err != nil {
 		t.Fatalf("error while getting entries from the mock api folder: %s", err)
@@ -233,13 +275,13 @@ func TestModifyMockApiFile(t *testing.T) {
 			continue
 		}
 		jsonFilescounter++
-		uuidString, found = strings.CutSuffix(file.Name(), ".json")
+		fileName, found = file.Name(), true
 	}
 	assert.True(t, found)
 	assert.Equal(t, 1, jsonFilescounter, "this means that some other json file is present in the test folder, jeopardizing the test result")
 
 	defer func() {
-		filename := folderPath + uuidString + ".json"
+		filename := folderPath + fileName
 		_, err := os.Stat(filename)
 		if err == nil {
 			err = os.Remove(filename)
@@ -249,19 +291,18 @@ func TestModifyMockApiFile(t *testing.T) {
 		}
 	}()
 
-	mockApiUuid64, err := strconv.ParseUint(uuidString, 10, 16)
-	if err != nil {
-		err := fmt.Errorf("error while parsing uuid '%s' into uint16", uuidString)
-		t.Fatal(err)
+	mockApis, err := LoadAPIsFromFolder()
+	assert.Nil(t, err)
+	var id string
+	for id = range mockApis {
 	}
-	uuid := uint16(mockApiUuid64)
 
 	// modify the mockApi file
 	newApi := api
-	newApi.Responses.Get = &map[string]interface{}{}
-	newApi.Responses.Post = &map[string]interface{}{}
-	newApi.Responses.Delete = &map[string]interface{}{}
-	newApi.Responses.Patch = &map[string]interface{}{}
+	newApi.Responses.Get = &common.MethodResponse{}
+	newApi.Responses.Post = &common.MethodResponse{}
+	newApi.Responses.Delete = &common.MethodResponse{}
+	newApi.Responses.Patch = &common.MethodResponse{}
 	if err = json.Unmarshal([]byte(`{"new_json":true,"new_body":"a new response"}`), newApi.Responses.Get); err != nil {
 		t.Fatal("error while unmarshalling")
 	}
@@ -278,16 +319,40 @@ func TestModifyMockApiFile(t *testing.T) {
 	if err != nil {
 		t.Fatalf("error while marshaling dummy mock api :%s", err)
 	}
-	assert.Nil(t, ModifyMockApiFile(uuid, newBytes))
+	assert.Nil(t, ModifyMockApiFile(id, newBytes))
 
-	// check it was modified
-	filename := folderPath + uuidString
-	filebytes, err := os.ReadFile(filename + ".json")
+	// check it was modified, keeping its id
+	filebytes, err := os.ReadFile(folderPath + fileName)
 	if err != nil {
 		t.Fatalf("error file not read :%s", err)
 	}
+	newApi.ID = id
+	newBytes, err = json.Marshal(newApi)
+	if err != nil {
+		t.Fatalf("error while marshaling dummy mock api :%s", err)
+	}
 	assert.Equal(t, newBytes, filebytes)
 
+	// the id can't be changed
+	newApi.ID = "another-id"
+	newBytes, err = json.Marshal(newApi)
+	if err != nil {
+		t.Fatalf("error while marshaling dummy mock api :%s", err)
+	}
+	assert.EqualError(t, ModifyMockApiFile(id, newBytes), fmt.Sprintf("the id of the mock api '%s' can't be changed into 'another-id'", id))
+
+	// an invalid modification leaves the file untouched
+	assert.NotNil(t, ModifyMockApiFile(id, []byte(`{"name":"","url":"url"}`)))
+	unchanged, err := os.ReadFile(folderPath + fileName)
+	assert.Nil(t, err)
+	assert.Equal(t, filebytes, unchanged)
+
+	// written with sane permissions, without leaving temporary files behind
+	info, err := os.Stat(folderPath + fileName)
+	assert.Nil(t, err)
+	assert.Equal(t, fs.FileMode(0644), info.Mode().Perm())
+	tmpFiles, _ := filepath.Glob(folderPath + "." + fileName + ".tmp-*")
+	assert.Empty(t, tmpFiles)
 }
 
 func TestLoadStoredAPIs(t *testing.T) {
@@ -303,16 +368,456 @@ func TestLoadStoredAPIs(t *testing.T) {
 	assert.Nil(t, err)
 
 	// add mock api
-	uuid, dummyMockApiFile, _ := writeDummyMockApiFile(t)
+	id, dummyMockApiFile, _ := writeDummyMockApiFile(t)
 	defer func() {
 		dummyMockApiFile.Close()
-		os.Remove(os.TempDir() + "/" + fmt.Sprint(uuid) + ".json")
+		os.Remove(os.TempDir() + "/" + id + ".json")
 	}()
 
 	// check that the apis have been loaded
 	mockApis, err := LoadAPIsFromFolder()
 	assert.Nil(t, err, "the function should return no error")
 	assert.Equal(t, 1, len(mockApis))
-	_, found := mockApis[uuid]
+	_, found := mockApis[id]
 	assert.True(t, found)
 }
+
+func TestLoadLegacyAndStructuredResponses(t *testing.T) {
+	reset()
+	folderPath = os.TempDir() + "/"
+
+	legacyFile := folderPath + "1001.json"
+	structuredFile := folderPath + "1002.json"
+	defer func() {
+		os.Remove(legacyFile)
+		os.Remove(structuredFile)
+	}()
+
+	legacy := `{"name":"legacy","url":"legacy-url","responses":{"get":{"status":"ok","body":"text"},"post":{}}}`
+	if err := os.WriteFile(legacyFile, []byte(legacy), 0644); err != nil {
+		t.Fatalf("error while writing the legacy file: %s", err)
+	}
+	structured := `{"name":"structured","url":"structured-url","responses":{"post":{"status":201,"headers":{"Location":"/users/1"},"body":{"id":1}},"delete":{"status":204}}}`
+	if err := os.WriteFile(structuredFile, []byte(structured), 0644); err != nil {
+		t.Fatalf("error while writing the structured file: %s", err)
+	}
+
+	mockApis, err := LoadAPIsFromFolder()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(mockApis))
+
+	// the legacy body is served as it is, with the default status
+	legacyApi := mockApis["legacy"]
+	assert.Equal(t, 200, legacyApi.Responses.Get.StatusCode())
+	assert.Equal(t, map[string]interface{}{"status": "ok", "body": "text"}, legacyApi.Responses.Get.Body)
+	assert.True(t, legacyApi.Responses.Post.IsEmpty())
+	assert.Nil(t, legacyApi.Responses.Patch)
+
+	structuredApi := mockApis["structured"]
+	assert.Equal(t, 201, structuredApi.Responses.Post.StatusCode())
+	assert.Equal(t, "/users/1", structuredApi.Responses.Post.Headers["Location"])
+	assert.Equal(t, map[string]interface{}{"id": float64(1)}, structuredApi.Responses.Post.Body)
+	assert.Equal(t, 204, structuredApi.Responses.Delete.StatusCode())
+	assert.Nil(t, structuredApi.Responses.Delete.Body)
+}
+
+func TestAddNewMockApiFileInvalidBody(t *testing.T) {
+	reset()
+	folderPath = os.TempDir() + "/"
+
+	// text bodies must be strings
+	body := `{"name":"text","url":"text-url","responses":{"get":{"bodyType":"text","body":{"not":"a string"}}}}`
+	assert.EqualError(t, AddNewMockApiFile([]byte(body)), "error while unmarshaling body: body of type 'text' must be a string")
+
+	// base64 bodies must be decodable
+	body = `{"name":"binary","url":"binary-url","responses":{"get":{"bodyType":"base64","body":"not base64!"}}}`
+	assert.EqualError(t, AddNewMockApiFile([]byte(body)), "error while unmarshaling body: invalid base64 body: illegal base64 data at input byte 3")
+
+	// unknown body types are rejected by the validator
+	body = `{"name":"unknown","url":"unknown-url","responses":{"get":{"bodyType":"xml","body":"<a/>"}}}`
+	assert.ErrorContains(t, AddNewMockApiFile([]byte(body)), "failed on the 'oneof' tag")
+}
+
+func TestLoadWireMockMappings(t *testing.T) {
+	reset()
+	folderPath = os.TempDir() + "/"
+
+	mappingFile := folderPath + "get-orders.json"
+	bodyFile := folderPath + "__files/orders-body.json"
+	defer func() {
+		os.Remove(mappingFile)
+		os.Remove(bodyFile)
+	}()
+
+	if err := os.MkdirAll(folderPath+"__files", 0755); err != nil {
+		t.Fatalf("error while creating the body files folder: %s", err)
+	}
+	if err := os.WriteFile(bodyFile, []byte(`[{"id":1}]`), 0644); err != nil {
+		t.Fatalf("error while writing the body file: %s", err)
+	}
+	mapping := `{
+		"request": {"method": "GET", "urlPath": "/orders"},
+		"response": {"status": 200, "headers": {"Content-Type": "application/json"}, "bodyFileName": "orders-body.json"}
+	}`
+	if err := os.WriteFile(mappingFile, []byte(mapping), 0644); err != nil {
+		t.Fatalf("error while writing the mapping file: %s", err)
+	}
+
+	mockApis, err := LoadAPIsFromFolder()
+	assert.Nil(t, err)
+	mockApi, found := mockApis["get-orders"]
+	if !found {
+		t.Fatal("the WireMock mapping was not loaded")
+	}
+	assert.Equal(t, "get-orders", mockApi.Name)
+	assert.Equal(t, "orders", mockApi.URL)
+	assert.Equal(t, common.BodyTypeJson, mockApi.Responses.Get.BodyType)
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": float64(1)}}, mockApi.Responses.Get.Body)
+
+	// mappings serving several urls are loaded as a list of mock apis
+	mappings := `{"mappings": [
+		{"name": "stub-orders", "request": {"urlPath": "/orders"}, "response": {"status": 200}},
+		{"name": "stub-users", "request": {"urlPath": "/users"}, "response": {"status": 204}}
+	]}`
+	if err := os.WriteFile(mappingFile, []byte(mappings), 0644); err != nil {
+		t.Fatalf("error while writing the mapping file: %s", err)
+	}
+	mockApis, err = LoadAPIsFromFolder()
+	assert.Nil(t, err)
+	assert.Equal(t, "orders", mockApis["stub-orders"].URL)
+	assert.Equal(t, 204, mockApis["stub-users"].Responses.Get.StatusCode())
+
+	// the mappings are not rewritten in the format of dynamocker
+	assert.EqualError(t, RemoveMockApiFile("stub-orders"), "the mock api 'stub-orders' is loaded from the WireMock mappings of the file get-orders.json, along with other mock apis: remove the mappings from the file")
+	body, _ := json.Marshal(mockApis["stub-users"])
+	assert.EqualError(t, ModifyMockApiFile("stub-users", body), "the mock api 'stub-users' is loaded from the WireMock mappings of the file get-orders.json: modify the mappings in the file")
+	data, err := os.ReadFile(mappingFile)
+	assert.Nil(t, err)
+	assert.Equal(t, mappings, string(data))
+
+	// the file of a single url is removed as a whole
+	mapping = `{"request": {"urlPath": "/orders"}, "response": {"status": 204}}`
+	if err := os.WriteFile(mappingFile, []byte(mapping), 0644); err != nil {
+		t.Fatalf("error while writing the mapping file: %s", err)
+	}
+	assert.Nil(t, RemoveMockApiFile("get-orders"))
+	_, err = os.Stat(mappingFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLoadWireMockRootFolder(t *testing.T) {
+	reset()
+	folderPath = t.TempDir()
+
+	// a WireMock root folder copied as it is, the body files being next to
+	// the mappings folder
+	write := func(relPath string, content string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(folderPath, relPath)), 0755); err != nil {
+			t.Fatalf("error while creating the folder of %s: %s", relPath, err)
+		}
+		if err := os.WriteFile(filepath.Join(folderPath, relPath), []byte(content), 0644); err != nil {
+			t.Fatalf("error while writing the file %s: %s", relPath, err)
+		}
+	}
+	write("wiremock/__files/orders.json", `[{"id":1}]`)
+	write("wiremock/mappings/orders.json", `{"request": {"urlPath": "/orders"}, "response": {"headers": {"Content-Type": "application/json"}, "bodyFileName": "orders.json"}}`)
+	write("wiremock/mappings/billing/invoices.json", `{"request": {"urlPath": "/invoices"}, "response": {"headers": {"Content-Type": "application/vnd.api+json"}, "jsonBody": {"data": []}}}`)
+
+	mockApis, err := LoadAPIsFromFolder()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(mockApis))
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": float64(1)}}, mockApis["orders"].Responses.Get.Body)
+
+	// the custom Content-Type of the json bodies is kept
+	invoices := mockApis["invoices"].Responses.Get
+	assert.Equal(t, map[string]interface{}{"data": []interface{}{}}, invoices.Body)
+	assert.Equal(t, "application/vnd.api+json", invoices.ContentType())
+}
+
+func TestResolveId(t *testing.T) {
+	// the id written in the file is kept
+	mockApi := common.MockApi{ID: "users-v2", Name: "users"}
+	assert.Nil(t, resolveId(&mockApi))
+	assert.Equal(t, "users-v2", mockApi.ID)
+
+	// the missing id derives from the name
+	mockApi = common.MockApi{Name: "Get /users?page=1"}
+	assert.Nil(t, resolveId(&mockApi))
+	assert.Equal(t, "get-users-page-1", mockApi.ID)
+	mockApi = common.MockApi{Name: "???"}
+	assert.Nil(t, resolveId(&mockApi))
+	assert.Equal(t, "mock-api", mockApi.ID)
+
+	// the id must fit in a url
+	mockApi = common.MockApi{ID: "users/1", Name: "users"}
+	assert.EqualError(t, resolveId(&mockApi), "invalid id 'users/1': only letters, digits, '.', '_', '~' and '-' are allowed")
+}
+
+func TestLoadYamlMockApis(t *testing.T) {
+	reset()
+	folderPath = os.TempDir() + "/"
+
+	yamlFile := folderPath + "1003.yaml"
+	ymlFile := folderPath + "1004.yml"
+	defer func() {
+		os.Remove(yamlFile)
+		os.Remove(ymlFile)
+	}()
+
+	mockApi := `# users served to the frontend
+name: yaml-users
+url: users
+responses:
+  get:
+    body:
+      - id: 1
+        name: John
+  post:
+    status: 201
+    bodyType: text
+    headers:
+      Content-Type: text/csv
+    # multi-line bodies are kept as they are
+    body: |
+      id,name
+      1,John
+`
+	if err := os.WriteFile(yamlFile, []byte(mockApi), 0644); err != nil {
+		t.Fatalf("error while writing the yaml file: %s", err)
+	}
+	if err := os.WriteFile(ymlFile, []byte("name: yml\nurl: yml\nresponses:\n  delete:\n    status: 204\n"), 0644); err != nil {
+		t.Fatalf("error while writing the yml file: %s", err)
+	}
+
+	mockApis, err := LoadAPIsFromFolder()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(mockApis))
+
+	yamlApi := mockApis["yaml-users"]
+	assert.Equal(t, "yaml-users", yamlApi.Name)
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": float64(1), "name": "John"}}, yamlApi.Responses.Get.Body)
+	assert.Equal(t, 201, yamlApi.Responses.Post.StatusCode())
+	assert.Equal(t, "id,name\n1,John\n", yamlApi.Responses.Post.Body)
+	assert.Equal(t, "text/csv", yamlApi.Responses.Post.ContentType())
+	assert.Equal(t, 204, mockApis["yml"].Responses.Delete.StatusCode())
+
+	// invalid yaml
+	_, err = DecodeMockApi(yamlFile, []byte("name: [unclosed"))
+	assert.ErrorContains(t, err, "error while converting the yaml into json")
+}
+
+func TestAddNewMockApiFileYaml(t *testing.T) {
+	reset()
+	folderPath = os.TempDir() + "/"
+	t.Setenv("DYNA_MOCK_API_FORMAT", "yaml")
+
+	api := dummyMockApi(t)
+	api.Responses.Get = &common.MethodResponse{ResponseDef: common.ResponseDef{BodyType: common.BodyTypeText, Body: "first line\nsecond line\n"}}
+	body, _ := json.Marshal(api)
+	assert.Nil(t, AddNewMockApiFile(body))
+
+	mockApis, err := LoadAPIsFromFolder()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mockApis))
+	var id string
+	for id = range mockApis {
+	}
+	fileName := folderPath + api.Name + ".yaml"
+	defer os.Remove(fileName)
+	assert.Equal(t, api.Name, mockApis[id].Name)
+	assert.Equal(t, api.Responses.Get, mockApis[id].Responses.Get)
+
+	// written in yaml, multi-line strings as literal blocks
+	data, err := os.ReadFile(fileName)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "name: "+api.Name)
+	assert.Contains(t, string(data), "body: |\n      first line\n      second line\n")
+
+	// the modified file keeps its format, whatever the configured one
+	t.Setenv("DYNA_MOCK_API_FORMAT", "json")
+	api.URL = "modified-url"
+	body, _ = json.Marshal(api)
+	assert.Nil(t, ModifyMockApiFile(id, body))
+	mockApis, _ = LoadAPIsFromFolder()
+	assert.Equal(t, "modified-url", mockApis[id].URL)
+	_, err = os.Stat(fileName)
+	assert.Nil(t, err)
+
+	assert.Nil(t, RemoveMockApiFile(id))
+	_, err = os.Stat(fileName)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestAddNewMockApiFileFolderWithoutSlash(t *testing.T) {
+	reset()
+	folderPath = filepath.Join(t.TempDir(), "mocks")
+	if err := os.Mkdir(folderPath, 0755); err != nil {
+		t.Fatalf("error while creating the mock folder: %s", err)
+	}
+
+	// the files are written inside the folder, also when the names clash
+	api := dummyMockApi(t)
+	api.Name = "users"
+	body, _ := json.Marshal(api)
+	assert.Nil(t, AddNewMockApiFile(body))
+	api.Name, api.URL = "users!", "other-url"
+	body, _ = json.Marshal(api)
+	assert.Nil(t, AddNewMockApiFile(body))
+
+	mockApis, err := LoadAPIsFromFolder()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(mockApis))
+	files, err := ListMockApiFiles(folderPath, "")
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"users.json", "users-2.json"}, files)
+}
+
+func TestLoadFolderTree(t *testing.T) {
+	reset()
+	folderPath = t.TempDir() + "/"
+
+	write := func(relPath string, content string) {
+		if err := os.MkdirAll(filepath.Dir(folderPath+relPath), 0755); err != nil {
+			t.Fatalf("error while creating the folder of %s: %s", relPath, err)
+		}
+		if err := os.WriteFile(folderPath+relPath, []byte(content), 0644); err != nil {
+			t.Fatalf("error while writing the file %s: %s", relPath, err)
+		}
+	}
+	write("users.json", `{"name":"users","url":"users","responses":{"get":{"status":204}}}`)
+	write("payments/orders.yaml", `
+- name: list-orders
+  url: orders
+  responses:
+    get:
+      body: []
+- name: get-order
+  url: orders/{id}
+  responses:
+    get:
+      body: {id: 1}
+- name: invalid
+  responses: {}
+`)
+	write("payments/refunds/refunds.json", `{"id":"refund-list","name":"refunds","url":"refunds","responses":{"get":{"status":204}}}`)
+	// body files of the WireMock mappings and hidden folders are skipped
+	write("__files/body.json", `{"id":1}`)
+	write(".git/config.json", `{}`)
+
+	mockApis, err := LoadAPIsFromFolder()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(mockApis))
+	assert.Equal(t, "users", mockApis["users"].Name)
+	listOrders, getOrder := "list-orders", "get-order"
+	assert.Equal(t, "orders", mockApis[listOrders].URL)
+	assert.Equal(t, "orders/{id}", mockApis[getOrder].URL)
+	assert.Equal(t, "refunds", mockApis["refund-list"].Name)
+	assert.Equal(t, map[string]string{"users": "users.json", listOrders: "payments/orders.yaml", getOrder: "payments/orders.yaml", "refund-list": "payments/refunds/refunds.json"}, MockApiSources())
+
+	// the mock apis sharing an id with the ones of another file are skipped
+	write("users2.json", `{"name":"users","url":"other-users","responses":{"get":{"status":204}}}`)
+	mockApis, _ = LoadAPIsFromFolder()
+	assert.Equal(t, 4, len(mockApis))
+	assert.Equal(t, "users", mockApis["users"].URL)
+	assert.Nil(t, os.Remove(folderPath+"users2.json"))
+
+	// a mock api of a list is modified in place, the other ones are kept
+	modified := *mockApis[getOrder]
+	modified.URL = "orders/{id:[0-9]+}"
+	body, _ := json.Marshal(modified)
+	assert.Nil(t, ModifyMockApiFile(getOrder, body))
+	mockApis, _ = LoadAPIsFromFolder()
+	assert.Equal(t, 4, len(mockApis))
+	assert.Equal(t, "orders/{id:[0-9]+}", mockApis[getOrder].URL)
+	assert.Equal(t, "orders", mockApis[listOrders].URL)
+
+	// removing the mock apis of a list, the invalid ones are kept
+	assert.Nil(t, RemoveMockApiFile(listOrders))
+	mockApis, _ = LoadAPIsFromFolder()
+	assert.Equal(t, 3, len(mockApis))
+	assert.Nil(t, RemoveMockApiFile(getOrder))
+	data, err := os.ReadFile(folderPath + "payments/orders.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "- name: invalid\n  responses: {}\n", string(data))
+
+	// the file goes with the last mock api of the list
+	write("payments/orders.yaml", `[{"name":"list-orders","url":"orders","responses":{"get":{"status":204}}}]`)
+	assert.Nil(t, RemoveMockApiFile(listOrders))
+	_, err = os.Stat(folderPath + "payments/orders.yaml")
+	assert.True(t, os.IsNotExist(err))
+
+	// the files of the subfolders are removed as well
+	assert.Nil(t, RemoveAllMockApisFiles())
+	mockApis, _ = LoadAPIsFromFolder()
+	assert.Empty(t, mockApis)
+	_, err = os.Stat(folderPath + "__files/body.json")
+	assert.Nil(t, err)
+}
+
+func TestValidateMockApi(t *testing.T) {
+	// valid mock api
+	mockApi, errs := ValidateMockApi([]byte(`{"name":"users","url":"users/{id}","responses":{"get":{"status":204}}}`))
+	assert.Empty(t, errs)
+	assert.Equal(t, "users", mockApi.Name)
+
+	// invalid json
+	mockApi, errs = ValidateMockApi([]byte("invalid json"))
+	assert.Nil(t, mockApi)
+	assert.Equal(t, []ValidationError{{Rule: "json", Message: "invalid character 'i' looking for beginning of value"}}, errs)
+	_, errs = ValidateMockApi([]byte(`{"name":5,"url":"users","responses":{"get":{"status":204}}}`))
+	assert.Equal(t, []ValidationError{{Field: "name", Rule: "type", Message: "expected a value of type string, found number"}}, errs)
+
+	// all the errors are listed, with the path of the json field
+	_, errs = ValidateMockApi([]byte(`{"id":"users/1","responses":{"get":{"status":700,"bodyType":"xml"},"post":{"candidates":[{"match":{"body":[{"jsonPath":"items[","equalTo":1}]}}]}}}`))
+	assert.Equal(t, []ValidationError{
+		{Field: "name", Rule: "required", Message: "'name' is required"},
+		{Field: "url", Rule: "required", Message: "'url' is required"},
+		{Field: "responses.get.status", Rule: "max", Message: "'responses.get.status' must be at most 599"},
+		{Field: "responses.get.bodyType", Rule: "oneof", Message: "'responses.get.bodyType' must be one of: json text base64"},
+		{Field: "id", Rule: "id", Message: "invalid id 'users/1': only letters, digits, '.', '_', '~' and '-' are allowed"},
+		{Field: "responses.post.candidates[0].match", Rule: "match", Message: "POST candidate 0: invalid jsonPath 'items[': it must start with '$'"},
+	}, errs)
+
+	// the errors found while unmarshaling the responses have the path too
+	_, errs = ValidateMockApi([]byte(`{"name":"users","url":"users","responses":{"get":{"bodyType":"base64","body":"!!"}}}`))
+	assert.Equal(t, []ValidationError{{Field: "responses.get.body", Rule: "base64", Message: "invalid base64 body: illegal base64 data at input byte 0"}}, errs)
+	_, errs = ValidateMockApi([]byte(`{"name":"users","url":"users","responses":{"put":{"sequence":[{"status":204},{"bodyType":"text","body":1}]}}}`))
+	assert.Equal(t, []ValidationError{{Field: "responses.put.sequence[1].body", Rule: "bodyType", Message: "sequence 1: body of type 'text' must be a string"}}, errs)
+	_, errs = ValidateMockApi([]byte(`{"name":"users","url":"users","responses":{"post":{"candidates":[{"match":{"query":{"q":{"matches":"("}}}}]}}}`))
+	assert.Equal(t, []ValidationError{{Field: "responses.post.candidates[0].match", Rule: "regex", Message: "candidate 0: invalid regex for 'q': error parsing regexp: missing closing ): `(`"}}, errs)
+	_, errs = ValidateMockApi([]byte(`{"name":"users","url":"users","responses":{"delete":5}}`))
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, "responses.delete", errs[0].Field)
+	assert.Equal(t, "type", errs[0].Rule)
+}
+
+func TestImportMockApis(t *testing.T) {
+	reset()
+	folderPath = os.TempDir() + "/"
+	defer func() {
+		os.Remove(folderPath + "import-first.json")
+		os.Remove(folderPath + "import-second.json")
+	}()
+
+	first := dummyMockApi(t)
+	first.Name, first.URL = "import-first", "import/first"
+	sameName := dummyMockApi(t)
+	sameName.Name, sameName.URL = "import-first", "import/other"
+	sameUrl := dummyMockApi(t)
+	sameUrl.Name, sameUrl.URL = "import-same-url", "import/first"
+	second := dummyMockApi(t)
+	second.Name, second.URL = "import-second", "import/second"
+
+	res := ImportMockApis([]*common.MockApi{&first, &sameName, &sameUrl, &second}, []string{"GET /skipped"})
+	assert.Equal(t, []string{"import-first", "import-second"}, res.Imported)
+	assert.Equal(t, []string{
+		"GET /skipped",
+		"mockApi 'import-first' not imported: another mockApi of the document has the same name",
+		"mockApi 'import-same-url' not imported: another mockApi of the document has the same URL 'import/first'",
+	}, res.Skipped)
+
+	// the stored mockApis are not imported again
+	res = ImportMockApis([]*common.MockApi{&second}, nil)
+	assert.Empty(t, res.Imported)
+	assert.Equal(t, []string{"mockApi 'import-second' not imported: found another mockApi with the same name 'import-second'"}, res.Skipped)
+}
@@ -0,0 +1,359 @@
+package mockapifilepkg
+
+import (
+	"dynamocker/internal/common"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// permissions of the mock api files
+const mockApiFilePerm fs.FileMode = 0644
+
+// store saving the mock apis as json (or yaml) files in the mock folder and
+// in its subfolders. A file holds a single mock api or a list of them, and can
+// be named freely. The changes are detected by the folder watcher
+type folderStore struct {
+	// path of the file of each mock api, relative to the mock folder
+	sources map[string]string
+}
+
+func (s *folderStore) Ready() error {
+	if folderPath == "" {
+		return fmt.Errorf("the mock API folder has not been set-up")
+	}
+	return nil
+}
+
+func (s *folderStore) Add(mockApi *common.MockApi) error {
+
+	// the files added by hand are looked for in the folder
+	stored, err := s.Load()
+	if err != nil {
+		return err
+	}
+	if relPath, found := s.sources[mockApi.ID]; found {
+		return fmt.Errorf("a mock api with id '%s' is already stored in the file %s", mockApi.ID, relPath)
+	}
+	if err := checkUnique(stored, mockApi.ID, mockApi); err != nil {
+		return err
+	}
+
+	// retrieve file path, named after the mock api
+	fileName := newFileName(mockApi.Name)
+	filePath := filepath.Join(folderPath, fileName)
+
+	// transform mockApi into []byte
+	bytes, err := encodeMockApiFile(mockApi, filePath)
+	if err != nil {
+		return fmt.Errorf("file %s not created. error while marshalling modified mockapi: %s", filePath, err)
+	}
+
+	// write mockapi
+	if err := writeFileAtomic(filePath, bytes); err != nil {
+		return fmt.Errorf("file %s not created: %s", filePath, err)
+	}
+
+	s.addSource(mockApi.ID, fileName)
+	return nil
+}
+
+func (s *folderStore) Remove(id string) error {
+
+	relPath, err := s.locate(id)
+
+	if err != nil {
+		return fmt.Errorf("error while getting entries from the mock api folder: %s", err)
+	}
+
+	// the other mock apis of a list are kept. The WireMock mappings are never
+	// rewritten, which would convert them into the format of dynamocker
+	list, isList, isWireMock, err := readMockApiList(relPath)
+	switch {
+	case err != nil:
+	case isList && isWireMock:
+		err = fmt.Errorf("the mock api '%s' is loaded from the WireMock mappings of the file %s, along with other mock apis: remove the mappings from the file", id, relPath)
+	case isList:
+		err = rewriteMockApiList(relPath, list, id, nil)
+	default:
+		if err = os.Remove(filepath.Join(folderPath, relPath)); err != nil {
+			err = fmt.Errorf("file %s not removed: %s", relPath, err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	delete(s.sources, id)
+	return nil
+}
+
+func (s *folderStore) RemoveAll() error {
+
+	files, err := ListMockApiFiles(folderPath, "")
+	if err != nil {
+		return fmt.Errorf("error while getting entries from the mock api folder: %s", err)
+	}
+
+	for _, relPath := range files {
+
+		if err = os.Remove(filepath.Join(folderPath, relPath)); err != nil {
+			return fmt.Errorf("file %s not removed: %s", relPath, err)
+		}
+
+	}
+
+	s.sources = nil
+	return nil
+}
+
+func (s *folderStore) Modify(mockApiId string, mockApi *common.MockApi) error {
+
+	stored, err := s.Load()
+	if err != nil {
+		return err
+	}
+	if err := checkUnique(stored, mockApiId, mockApi); err != nil {
+		return err
+	}
+
+	relPath, err := s.locate(mockApiId)
+
+	if err != nil {
+		return fmt.Errorf("error while getting entries from the mock api folder: %s", err)
+	}
+
+	// the mock api is replaced in the list of the file. The WireMock mappings
+	// are never rewritten, which would convert them into the format of dynamocker
+	if list, isList, isWireMock, err := readMockApiList(relPath); err != nil {
+		return err
+	} else if isWireMock {
+		return fmt.Errorf("the mock api '%s' is loaded from the WireMock mappings of the file %s: modify the mappings in the file", mockApiId, relPath)
+	} else if isList {
+		return rewriteMockApiList(relPath, list, mockApiId, mockApi)
+	}
+
+	// retrieve file path, the file keeps its format
+	filePath := filepath.Join(folderPath, relPath)
+
+	// transform mockApi into []byte, the file is replaced only once encoded
+	bytes, err := encodeMockApiFile(mockApi, filePath)
+	if err != nil {
+		return fmt.Errorf("file %s not created. error while marshalling modified mockapi: %s", filePath, err)
+	}
+
+	// write mockapi
+	if err := writeFileAtomic(filePath, bytes); err != nil {
+		return fmt.Errorf("file %s not created: %s", filePath, err)
+	}
+
+	return nil
+}
+
+func (s *folderStore) Load() (map[string]*common.MockApi, error) {
+
+	mockApiList := make(map[string]*common.MockApi)
+	sources := make(map[string]string)
+
+	// the *.json, *.yaml and *.yml files of the folder and its subfolders
+	files, err := ListMockApiFiles(folderPath, "")
+	if err != nil {
+		return nil, fmt.Errorf("error while getting entries from the mock api folder: %s", err)
+	}
+
+	for _, relPath := range files {
+
+		// read content
+		pathToFile := filepath.Join(folderPath, relPath)
+		byteValue, err := os.ReadFile(pathToFile)
+		if err != nil {
+			log.Errorf("error while reading the file %s: %s", pathToFile, err)
+			continue
+		}
+
+		// decode, validate and check content
+		mockApis, err := DecodeMockApiFile(folderPath, relPath, byteValue)
+		if err != nil {
+			log.Errorf("invalid mock api saved in the file %s: %s", pathToFile, err)
+			continue
+		}
+
+		// add to the map
+		for id, mockApi := range mockApis {
+			if other, found := sources[id]; found {
+				log.Errorf("mock api '%s' of the file %s skipped: same id '%s' of a mock api of the file %s", mockApi.Name, relPath, id, other)
+				continue
+			}
+			mockApiList[id] = mockApi
+			sources[id] = relPath
+		}
+
+	}
+
+	s.sources = sources
+	return mockApiList, nil
+}
+
+func (s *folderStore) Close() error {
+	return nil
+}
+
+// look for the file of the mock api, relative to the mock folder. The files
+// added by hand after the last load are looked for in the folder
+func (s *folderStore) locate(id string) (string, error) {
+	if relPath, found := s.sources[id]; found {
+		if _, err := os.Stat(filepath.Join(folderPath, relPath)); err == nil {
+			return relPath, nil
+		}
+	}
+	if _, err := s.Load(); err != nil {
+		return "", err
+	}
+	if relPath, found := s.sources[id]; found {
+		return relPath, nil
+	}
+	return "", fmt.Errorf("no mock api with id '%s' found", id)
+}
+
+func (s *folderStore) addSource(id string, relPath string) {
+	if s.sources == nil {
+		s.sources = make(map[string]string)
+	}
+	s.sources[id] = relPath
+}
+
+// name of the file of a new mock api, in the root of the mock folder, after
+// the name of the mock api. A suffix is added if the name is already taken
+func newFileName(name string) string {
+	base, ext := slugify(name), newFileExtension()
+	fileName := base + ext
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(folderPath, fileName)); errors.Is(err, fs.ErrNotExist) {
+			return fileName
+		}
+		fileName = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
+// MockApiSources returns the path of the file of each mock api loaded from the
+// mock folder, relative to it. It is empty if the mock apis are not stored in
+// the folder
+func MockApiSources() map[string]string {
+	mu.Lock()
+	defer mu.Unlock()
+	sources := make(map[string]string)
+	if folder, isFolder := store.(*folderStore); isFolder {
+		for id, relPath := range folder.sources {
+			sources[id] = relPath
+		}
+	}
+	return sources
+}
+
+// read the file of the mock folder, returning the list of mock apis it holds
+// and whether it holds WireMock mappings. The list is not returned (false) if
+// the file holds a single mock api
+func readMockApiList(relPath string) ([]json.RawMessage, bool, bool, error) {
+	pathToFile := filepath.Join(folderPath, relPath)
+	data, err := os.ReadFile(pathToFile)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("error while reading the file %s: %s", relPath, err)
+	}
+	list, isList, err := mockApiList(pathToFile, data)
+	return list, isList, isWireMockFile(pathToFile, data), err
+}
+
+// write again the list of mock apis of the file, replacing the one with the
+// given id or, if mockApi is nil, removing it. The file is removed once the
+// list is empty
+func rewriteMockApiList(relPath string, list []json.RawMessage, id string, mockApi *common.MockApi) error {
+	pathToFile := filepath.Join(folderPath, relPath)
+	newList := make([]json.RawMessage, 0, len(list))
+	found := false
+	for _, element := range list {
+		var item common.MockApi
+		if json.Unmarshal(element, &item) != nil || resolveId(&item) != nil || item.ID != id {
+			newList = append(newList, element)
+			continue
+		}
+		found = true
+		if mockApi != nil {
+			data, err := json.Marshal(mockApi)
+			if err != nil {
+				return fmt.Errorf("file %s not modified. error while marshalling modified mockapi: %s", relPath, err)
+			}
+			newList = append(newList, data)
+		}
+	}
+	if !found {
+		return fmt.Errorf("mock api '%s' not found in the file %s", id, relPath)
+	}
+
+	if len(newList) == 0 {
+		if err := os.Remove(pathToFile); err != nil {
+			return fmt.Errorf("file %s not removed: %s", relPath, err)
+		}
+		return nil
+	}
+
+	bytes, err := encodeMockApiFile(newList, pathToFile)
+	if err != nil {
+		return fmt.Errorf("file %s not modified. error while marshalling the mock apis: %s", relPath, err)
+	}
+	if err := writeFileAtomic(pathToFile, bytes); err != nil {
+		return fmt.Errorf("file %s not modified: %s", relPath, err)
+	}
+	return nil
+}
+
+// write the file atomically: the content is written to a hidden temporary file
+// of the same folder, flushed to disk and renamed over the file. The folder is
+// flushed too, so that the rename survives a crash. The file is never missing
+// or half-written, neither for the watcher nor after a crash
+func writeFileAtomic(filePath string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	// the temporary file is removed if anything goes wrong
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mockApiFilePerm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return syncDir(filepath.Dir(filePath))
+}
+
+// flush the entries of the folder to disk
+func syncDir(dir string) error {
+	folder, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer folder.Close()
+	return folder.Sync()
+}
@@ -0,0 +1,166 @@
+package mockapifilepkg
+
+import (
+	"bytes"
+	"dynamocker/internal/common"
+	"dynamocker/internal/config"
+	wiremockpkg "dynamocker/internal/wiremock"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// extensions of the files holding a mock api. The yaml files share the schema
+// of the json ones
+var mockApiExtensions = []string{".json", ".yaml", ".yml"}
+
+// IsMockApiFile returns true if the extension of the file is the one of a mock
+// api file (json or yaml)
+func IsMockApiFile(fileName string) bool {
+	return mockApiExtension(fileName) != ""
+}
+
+// returns the extension of the mock api file, empty if not supported
+func mockApiExtension(fileName string) string {
+	ext := filepath.Ext(fileName)
+	for _, supported := range mockApiExtensions {
+		if ext == supported {
+			return ext
+		}
+	}
+	return ""
+}
+
+func isYaml(fileName string) bool {
+	ext := mockApiExtension(fileName)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// extension of the new mock api files, depending on the configured format
+func newFileExtension() string {
+	switch config.GetMockApiFormat() {
+	case "yaml", "yml":
+		return ".yaml"
+	default:
+		return ".json"
+	}
+}
+
+// encode the content of a mock api file (a mockApi or a list of them) in the
+// format of the file: yaml or json
+func encodeMockApiFile(content interface{}, fileName string) ([]byte, error) {
+	data, err := json.Marshal(content)
+	if err != nil || !isYaml(fileName) {
+		return data, err
+	}
+
+	// converted to a generic value first, to keep the json field names
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(raw); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// IgnoredFolder returns true if the subfolder of the mock folder doesn't hold
+// mock api files: the hidden ones and the '__files' ones, holding the body
+// files of the WireMock mappings
+func IgnoredFolder(name string) bool {
+	return name == "__files" || strings.HasPrefix(name, ".")
+}
+
+// ListMockApiFiles lists the mock api files in the subfolder dir of the mock
+// folder and in its subfolders. The paths are relative to the mock folder
+func ListMockApiFiles(folder string, dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(folder, dir))
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0)
+	for _, entry := range entries {
+		relPath := path.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if IgnoredFolder(entry.Name()) {
+				continue
+			}
+			subFiles, err := ListMockApiFiles(folder, relPath)
+			if err != nil {
+				log.Errorf("error while getting entries from the folder %s: %s", relPath, err)
+				continue
+			}
+			files = append(files, subFiles...)
+			continue
+		}
+		if IsMockApiFile(entry.Name()) {
+			files = append(files, relPath)
+		}
+	}
+	return files, nil
+}
+
+// the mock apis of a file holding a list of them, in json. It returns false if
+// the file holds a single mock api. The WireMock mappings serving several urls
+// are a list of mock apis, converted into the format of dynamocker
+func mockApiList(pathToFile string, data []byte) ([]json.RawMessage, bool, error) {
+	if isYaml(pathToFile) {
+		jsonData, err := common.YamlToJson(data)
+		if err != nil {
+			return nil, false, fmt.Errorf("error while converting the yaml into json: %s", err)
+		}
+		data = jsonData
+	}
+	if wiremockpkg.IsMapping(data) {
+		return wireMockList(pathToFile, data)
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return nil, false, nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, true, fmt.Errorf("error while unmarshaling the list of mock apis: %s", err)
+	}
+	return list, true, nil
+}
+
+// returns true if the file holds WireMock mappings
+func isWireMockFile(pathToFile string, data []byte) bool {
+	if isYaml(pathToFile) {
+		jsonData, err := common.YamlToJson(data)
+		if err != nil {
+			return false
+		}
+		data = jsonData
+	}
+	return wiremockpkg.IsMapping(data)
+}
+
+func wireMockList(pathToFile string, data []byte) ([]json.RawMessage, bool, error) {
+	mockApis, err := wireMockMockApis(pathToFile, data)
+	if err != nil || len(mockApis) == 1 {
+		return nil, false, err
+	}
+	list := make([]json.RawMessage, 0, len(mockApis))
+	for _, mockApi := range mockApis {
+		element, err := json.Marshal(mockApi)
+		if err != nil {
+			return nil, true, fmt.Errorf("error while converting the WireMock mappings: %s", err)
+		}
+		list = append(list, element)
+	}
+	return list, true, nil
+}
@@ -0,0 +1,48 @@
+package mockapifilepkg
+
+import (
+	"dynamocker/internal/common"
+	urlpatternpkg "dynamocker/internal/url-pattern"
+	"encoding/json"
+	"fmt"
+)
+
+// ImportResult lists the names of the imported mockApis and the reasons why
+// the other elements of the imported document have been skipped
+type ImportResult struct {
+	Imported []string `json:"imported"`
+	Skipped  []string `json:"skipped"`
+}
+
+// ImportMockApis adds a file for each mockApi converted from an imported
+// document. The mockApis failing the validation, or whose name or url is
+// already used by a stored mockApi or by another mockApi of the document, are
+// skipped.
+func ImportMockApis(mockApis []*common.MockApi, skipped []string) ImportResult {
+	res := ImportResult{Imported: make([]string, 0), Skipped: append(make([]string, 0), skipped...)}
+	names := make(map[string]bool, len(mockApis))
+	urls := make(map[string]bool, len(mockApis))
+	for _, mockApi := range mockApis {
+		if names[mockApi.Name] {
+			res.Skipped = append(res.Skipped, fmt.Sprintf("mockApi '%s' not imported: another mockApi of the document has the same name", mockApi.Name))
+			continue
+		}
+		if urls[urlpatternpkg.Key(mockApi.URL)] {
+			res.Skipped = append(res.Skipped, fmt.Sprintf("mockApi '%s' not imported: another mockApi of the document has the same URL '%s'", mockApi.Name, mockApi.URL))
+			continue
+		}
+		names[mockApi.Name] = true
+		urls[urlpatternpkg.Key(mockApi.URL)] = true
+
+		body, err := json.Marshal(mockApi)
+		if err == nil {
+			err = AddNewMockApiFile(body)
+		}
+		if err != nil {
+			res.Skipped = append(res.Skipped, fmt.Sprintf("mockApi '%s' not imported: %s", mockApi.Name, err))
+			continue
+		}
+		res.Imported = append(res.Imported, mockApi.Name)
+	}
+	return res
+}
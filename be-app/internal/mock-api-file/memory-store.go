@@ -0,0 +1,110 @@
+package mockapifilepkg
+
+import (
+	"dynamocker/internal/common"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// store keeping the mock apis in memory, e.g. for ephemeral test runs. The
+// mock apis are saved as json, so that the loaded ones are independent copies
+type memoryStore struct {
+	mu       sync.Mutex
+	mockApis map[string][]byte
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{mockApis: make(map[string][]byte)}
+}
+
+func (s *memoryStore) Ready() error {
+	return nil
+}
+
+func (s *memoryStore) Add(mockApi *common.MockApi) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.mockApis[mockApi.ID]; found {
+		return fmt.Errorf("a mock api with id '%s' is already stored", mockApi.ID)
+	}
+	if err := s.checkUnique(mockApi.ID, mockApi); err != nil {
+		return err
+	}
+	data, err := json.Marshal(mockApi)
+	if err != nil {
+		return fmt.Errorf("mock api not stored. error while marshalling mockapi: %s", err)
+	}
+	s.mockApis[mockApi.ID] = data
+	return nil
+}
+
+func (s *memoryStore) Modify(id string, mockApi *common.MockApi) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.mockApis[id]; !found {
+		return fmt.Errorf("no mock api with id '%s' stored", id)
+	}
+	if err := s.checkUnique(id, mockApi); err != nil {
+		return err
+	}
+	data, err := json.Marshal(mockApi)
+	if err != nil {
+		return fmt.Errorf("mock api not stored. error while marshalling mockapi: %s", err)
+	}
+	s.mockApis[id] = data
+	return nil
+}
+
+func (s *memoryStore) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.mockApis[id]; !found {
+		return fmt.Errorf("no mock api with id '%s' stored", id)
+	}
+	delete(s.mockApis, id)
+	return nil
+}
+
+func (s *memoryStore) RemoveAll() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mockApis = make(map[string][]byte)
+	return nil
+}
+
+func (s *memoryStore) Load() (map[string]*common.MockApi, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load()
+}
+
+// the mock apis must be locked by the caller
+func (s *memoryStore) checkUnique(id string, mockApi *common.MockApi) error {
+	stored, err := s.load()
+	if err != nil {
+		return err
+	}
+	return checkUnique(stored, id, mockApi)
+}
+
+func (s *memoryStore) load() (map[string]*common.MockApi, error) {
+	mockApis := make(map[string]*common.MockApi, len(s.mockApis))
+	for id, data := range s.mockApis {
+		var mockApi common.MockApi
+		if err := json.Unmarshal(data, &mockApi); err != nil {
+			return nil, fmt.Errorf("error while unmarshaling the mock api '%s': %s", id, err)
+		}
+		mockApis[id] = &mockApi
+	}
+	return mockApis, nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
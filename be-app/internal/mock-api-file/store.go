@@ -0,0 +1,89 @@
+package mockapifilepkg
+
+import (
+	"dynamocker/internal/common"
+	urlpatternpkg "dynamocker/internal/url-pattern"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// kinds of store, selected through the DYNA_STORE env variable
+const (
+	// a file per mock api in the mock folder, observed for changes
+	StoreFolder = "folder"
+	// mock apis kept in memory only, lost at shutdown
+	StoreMemory = "memory"
+	// mock apis saved in a single database file
+	StoreDatabase = "db"
+)
+
+// Store persists the mock apis, identified by their id. The mock apis are
+// validated, and their id set, before being stored.
+type Store interface {
+	// Ready returns an error if the store can't be used
+	Ready() error
+	// Load returns all the stored mock apis, indexed by id
+	Load() (map[string]*common.MockApi, error)
+	// Add stores a new mock api. It fails if its id is already used, or if
+	// another mock api has the same name or the same url
+	Add(mockApi *common.MockApi) error
+	// Modify replaces the mock api with the given id. It fails if another mock
+	// api has the same name or the same url
+	Modify(id string, mockApi *common.MockApi) error
+	// Remove removes the mock api with the given id
+	Remove(id string) error
+	// RemoveAll removes all the mock apis
+	RemoveAll() error
+	// Close releases the resources held by the store
+	Close() error
+}
+
+// check that none of the stored mock apis, but the one with the given id, has
+// the same name or the same url of the mock api. The urls matching the same
+// paths (e.g. 'users' and '/users/') are the same url
+func checkUnique(stored map[string]*common.MockApi, id string, mockApi *common.MockApi) error {
+	for otherId, other := range stored {
+		if otherId != id && other.Name == mockApi.Name {
+			return fmt.Errorf("found another mockApi with the same name '%s'", mockApi.Name)
+		}
+	}
+	urlKey := urlpatternpkg.Key(mockApi.URL)
+	for otherId, other := range stored {
+		if otherId != id && urlpatternpkg.Key(other.URL) == urlKey {
+			return fmt.Errorf("found another mockApi with the same URL '%s'", mockApi.URL)
+		}
+	}
+	return nil
+}
+
+// ids are used in the urls of the management api: letters, digits, '.', '_',
+// '~' and '-' are allowed
+var idRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._~-]*$`)
+
+func checkId(id string) error {
+	if !idRegex.MatchString(id) {
+		return fmt.Errorf("invalid id '%s': only letters, digits, '.', '_', '~' and '-' are allowed", id)
+	}
+	return nil
+}
+
+var notIdChars = regexp.MustCompile(`[^a-z0-9._~-]+`)
+
+// turn the name of a mock api into an id, or a file name
+func slugify(name string) string {
+	slug := strings.Trim(notIdChars.ReplaceAllString(strings.ToLower(name), "-"), "-._~")
+	if slug == "" {
+		return "mock-api"
+	}
+	return slug
+}
+
+// set the id of the mock api, derived from its name if not defined, and check
+// it
+func resolveId(mockApi *common.MockApi) error {
+	if mockApi.ID == "" {
+		mockApi.ID = slugify(mockApi.Name)
+	}
+	return checkId(mockApi.ID)
+}
@@ -0,0 +1,147 @@
+package mockapifilepkg
+
+import (
+	"dynamocker/internal/common"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// use the store selected through the env variables, restoring the folder
+// store at the end of the test
+func useStore(t *testing.T, kind string, file string) {
+	t.Setenv("DYNA_STORE", kind)
+	t.Setenv("DYNA_STORE_FILE", file)
+	t.Setenv("DYNA_MOCK_API_FOLDER", os.TempDir()+"/")
+	if err := Init(); err != nil {
+		t.Fatalf("error while initializing the store: %s", err)
+	}
+	t.Cleanup(func() {
+		SetListener(nil)
+		store.Close()
+		store = &folderStore{}
+	})
+}
+
+func testStore(t *testing.T) {
+	changes := make(map[string]*common.MockApi)
+	SetListener(func(id string, mockApi *common.MockApi) {
+		changes[id] = mockApi
+	})
+	assert.False(t, WatchedFolder())
+
+	mockApis, err := LoadAPIsFromFolder()
+	assert.Nil(t, err)
+	assert.Empty(t, mockApis)
+
+	api := dummyMockApi(t)
+	body, _ := json.Marshal(api)
+	assert.Nil(t, AddNewMockApiFile(body))
+	assert.EqualError(t, AddNewMockApiFile([]byte("invalid json")), "error while unmarshaling body: invalid character 'i' looking for beginning of value")
+
+	mockApis, err = LoadAPIsFromFolder()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mockApis))
+	var id string
+	for id = range mockApis {
+	}
+	assert.Equal(t, api.Name, mockApis[id].Name)
+	assert.Equal(t, api.Responses.Get, mockApis[id].Responses.Get)
+	assert.Equal(t, api.Name, changes[id].Name)
+
+	// modify
+	api.URL = "modified-url"
+	body, _ = json.Marshal(api)
+	assert.Nil(t, ModifyMockApiFile(id, body))
+	assert.EqualError(t, ModifyMockApiFile("other", body), "no mock api with id 'other' stored")
+	mockApis, _ = LoadAPIsFromFolder()
+	assert.Equal(t, "modified-url", mockApis[id].URL)
+	assert.Equal(t, "modified-url", changes[id].URL)
+
+	// the loaded mockApis are copies
+	mockApis[id].URL = "changed"
+	mockApis, _ = LoadAPIsFromFolder()
+	assert.Equal(t, "modified-url", mockApis[id].URL)
+
+	// remove
+	assert.Nil(t, RemoveMockApiFile(id))
+	assert.EqualError(t, RemoveMockApiFile(id), "no mock api with id '"+id+"' stored")
+	_, found := changes[id]
+	assert.True(t, found)
+	assert.Nil(t, changes[id])
+
+	// the id chosen by the user must be unique
+	api.ID = "chosen-id"
+	body, _ = json.Marshal(api)
+	assert.Nil(t, AddNewMockApiFile(body))
+	assert.ErrorContains(t, AddNewMockApiFile(body), "a mock api with id 'chosen-id' is already stored")
+	assert.Equal(t, api.Name, changes["chosen-id"].Name)
+
+	// the name and the url must be unique too
+	other := dummyMockApi(t)
+	other.Name = api.Name
+	other.URL = "other-url"
+	body, _ = json.Marshal(other)
+	assert.ErrorContains(t, AddNewMockApiFile(body), "found another mockApi with the same name '"+api.Name+"'")
+	other.Name = "other"
+	body, _ = json.Marshal(other)
+	assert.Nil(t, AddNewMockApiFile(body))
+	mockApis, _ = LoadAPIsFromFolder()
+	for id = range mockApis {
+		if id != "chosen-id" {
+			break
+		}
+	}
+	other.URL = api.URL
+	body, _ = json.Marshal(other)
+	assert.ErrorContains(t, ModifyMockApiFile(id, body), "found another mockApi with the same URL '"+api.URL+"'")
+	other.URL = "/" + api.URL + "/"
+	body, _ = json.Marshal(other)
+	assert.ErrorContains(t, ModifyMockApiFile(id, body), "found another mockApi with the same URL '/"+api.URL+"/'")
+
+	// remove all
+	assert.Nil(t, RemoveAllMockApisFiles())
+	mockApis, _ = LoadAPIsFromFolder()
+	assert.Empty(t, mockApis)
+	for _, mockApi := range changes {
+		assert.Nil(t, mockApi)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	reset()
+	useStore(t, StoreMemory, "")
+	testStore(t)
+}
+
+func TestDatabaseStore(t *testing.T) {
+	reset()
+	dbFile := filepath.Join(t.TempDir(), "mocks.db")
+	useStore(t, StoreDatabase, dbFile)
+	testStore(t)
+
+	// the mockApis are kept in the file across restarts
+	body, _ := json.Marshal(dummyMockApi(t))
+	assert.Nil(t, AddNewMockApiFile(body))
+	assert.Nil(t, Init())
+	mockApis, err := LoadAPIsFromFolder()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mockApis))
+}
+
+func TestInitStore(t *testing.T) {
+	reset()
+	useStore(t, StoreFolder, "")
+	assert.True(t, WatchedFolder())
+
+	t.Setenv("DYNA_STORE", "cloud")
+	assert.EqualError(t, Init(), "unknown store 'cloud': expected one of folder, memory, db")
+	assert.True(t, WatchedFolder())
+
+	t.Setenv("DYNA_STORE", StoreDatabase)
+	t.Setenv("DYNA_STORE_FILE", filepath.Join(t.TempDir(), "missing", "mocks.db"))
+	assert.ErrorContains(t, Init(), "error while opening the database")
+}
@@ -0,0 +1,142 @@
+package mockapifilepkg
+
+import (
+	"dynamocker/internal/common"
+	requestmatcherpkg "dynamocker/internal/request-matcher"
+	urlpatternpkg "dynamocker/internal/url-pattern"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationError describes why a field of a mock api is not valid. The field
+// is the path of the json field (e.g. 'responses.get.status'), empty if the
+// error concerns the whole mock api
+type ValidationError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidateMockApi runs on the body of a request adding or modifying a mock api
+// the checks of AddNewMockApiFile, without storing it. It returns the
+// unmarshaled mock api, nil if the body can't be unmarshaled, and the list of
+// the errors found
+func ValidateMockApi(body []byte) (*common.MockApi, []ValidationError) {
+
+	// unmarshal body
+	var mockApi common.MockApi
+	if err := json.Unmarshal(body, &mockApi); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, []ValidationError{{Field: typeErr.Field, Rule: "type", Message: fmt.Sprintf("expected a value of type %s, found %s", typeErr.Type, typeErr.Value)}}
+		}
+		var fieldErr *common.FieldError
+		if errors.As(err, &fieldErr) {
+			return nil, []ValidationError{{Field: fieldErr.Field, Rule: fieldErr.Rule, Message: fieldErr.Message}}
+		}
+		return nil, []ValidationError{{Rule: "json", Message: err.Error()}}
+	}
+
+	// validate body, the fields are named after their json names
+	errs := make([]ValidationError, 0)
+	vtor := validator.New(validator.WithRequiredStructEnabled())
+	vtor.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	if err := vtor.Struct(mockApi); err != nil {
+		var valErrs validator.ValidationErrors
+		if !errors.As(err, &valErrs) {
+			return &mockApi, []ValidationError{{Rule: "validator", Message: err.Error()}}
+		}
+		for _, valErr := range valErrs {
+			field := fieldPath(valErr.Namespace())
+			errs = append(errs, ValidationError{Field: field, Rule: valErr.Tag(), Message: ruleMessage(field, valErr.Tag(), valErr.Param())})
+		}
+	}
+
+	// check id, url pattern and matchers
+	return &mockApi, append(errs, checkMockApi(&mockApi)...)
+}
+
+// path of the json field out of the namespace of the validator, e.g.
+// 'MockApi.responses.get.ResponseDef.status'. The root struct and the
+// embedded ones, named after their type, are skipped
+func fieldPath(namespace string) string {
+	segments := strings.Split(namespace, ".")
+	path := make([]string, 0, len(segments))
+	for _, segment := range segments[1:] {
+		if segment != "" && unicode.IsUpper(rune(segment[0])) {
+			continue
+		}
+		path = append(path, segment)
+	}
+	return strings.Join(path, ".")
+}
+
+func ruleMessage(field string, rule string, param string) string {
+	switch rule {
+	case "required":
+		return fmt.Sprintf("'%s' is required", field)
+	case "required_if":
+		return fmt.Sprintf("'%s' is required when %s", field, param)
+	case "oneof":
+		return fmt.Sprintf("'%s' must be one of: %s", field, param)
+	case "min":
+		return fmt.Sprintf("'%s' must be at least %s", field, param)
+	case "max":
+		return fmt.Sprintf("'%s' must be at most %s", field, param)
+	case "gtefield":
+		return fmt.Sprintf("'%s' must be greater than or equal to '%s'", field, param)
+	default:
+		return fmt.Sprintf("'%s' failed on the '%s' rule", field, rule)
+	}
+}
+
+// the checks not covered by the validator, see CheckMockApi. The responses are
+// checked in the order of the methods
+func checkMockApi(mockApi *common.MockApi) []ValidationError {
+	errs := make([]ValidationError, 0)
+	if mockApi.ID != "" {
+		if err := checkId(mockApi.ID); err != nil {
+			errs = append(errs, ValidationError{Field: "id", Rule: "id", Message: err.Error()})
+		}
+	}
+	// the missing url is reported by the validator
+	if _, err := urlpatternpkg.Compile(mockApi.URL); mockApi.URL != "" && err != nil {
+		errs = append(errs, ValidationError{Field: "url", Rule: "pattern", Message: err.Error()})
+	}
+	responses := mockApi.Responses.ByMethod()
+	methods := make([]string, 0, len(responses))
+	for method := range responses {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		response := responses[method]
+		field := "responses." + strings.ToLower(method)
+		if err := checkTemplates(response.ResponseDef, response.Sequence); err != nil {
+			errs = append(errs, ValidationError{Field: field, Rule: "template", Message: fmt.Sprintf("%s template: %s", method, err)})
+		}
+		for i, candidate := range response.Candidates {
+			candidateField := fmt.Sprintf("%s.candidates[%d]", field, i)
+			if err := requestmatcherpkg.Check(candidate.Match); err != nil {
+				errs = append(errs, ValidationError{Field: candidateField + ".match", Rule: "match", Message: fmt.Sprintf("%s candidate %d: %s", method, i, err)})
+			}
+			if err := checkTemplates(candidate.ResponseDef, candidate.Sequence); err != nil {
+				errs = append(errs, ValidationError{Field: candidateField, Rule: "template", Message: fmt.Sprintf("%s candidate %d template: %s", method, i, err)})
+			}
+		}
+	}
+	return errs
+}
@@ -4,24 +4,29 @@ import (
 	"dynamocker/internal/common"
 	"dynamocker/internal/config"
 	mockapifilepkg "dynamocker/internal/mock-api-file"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
-	"github.com/go-playground/validator/v10"
 	log "github.com/sirupsen/logrus"
 )
 
 var folderPath = ""
 
-var mockApiList = make(map[uint16]*common.MockApi)
+// MockApis currently served
+var registry = NewRegistry()
+
+// path of the file of each mockApi loaded from the folder, relative to it. It
+// tells the mockApis to be removed along with a file or a subfolder
+var sources = make(map[string]string)
+var sourcesMu sync.Mutex
 
 func Init(closeAll chan bool, wg *sync.WaitGroup) error {
 
@@ -29,73 +34,105 @@ func Init(closeAll chan bool, wg *sync.WaitGroup) error {
 	if err != nil {
 		return err
 	}
-	mockApiList = make(map[uint16]*common.MockApi)
+	registry = NewRegistry()
 	folderPath = config.GetMockApiFolder()
 
 	// load the stored APIs for the first time
-	mockApiList, err = mockapifilepkg.LoadAPIsFromFolder()
-	for uuid := range mockApiList {
-		log.Infof("mockApi %d was succesfully loaded", uuid)
-	}
+	mockApis, err := mockapifilepkg.LoadAPIsFromFolder()
 	if err != nil {
 		return err
 	}
+	registry.Replace(mockApis)
+	setSources(mockapifilepkg.MockApiSources())
+	for id := range mockApis {
+		log.Infof("mockApi '%s' was succesfully loaded", id)
+	}
 
-	// periodically poll from the folder
+	// the stores other than the folder notify their changes
+	mockapifilepkg.SetListener(storeChanged)
+
+	// periodically poll from the store
 	// safe mechanism to recover from not-working observing goroutine
 	wg.Add(1)
 	go backUpPollingCycle(closeAll, wg)
-	wg.Add(1)
-	go observeFolder(closeAll, wg)
+	if mockapifilepkg.WatchedFolder() {
+		wg.Add(1)
+		go observeFolder(closeAll, wg)
+	}
 	time.Sleep(500 * time.Millisecond) // let goroutines start
 	log.Info("mocking-mgmt terminated the initialization phase")
 	return nil
 }
 
 func GetMockAPIs() []*common.MockApi {
-	ret := make([]*common.MockApi, 0)
-	for _, mockApi := range mockApiList {
-		ret = append(ret, mockApi)
-	}
-	return ret
+	return registry.List()
 }
 
-func GetMockAPI(uuid uint16) (*common.MockApi, error) {
-	mockApi, found := mockApiList[uuid]
+func GetMockAPI(id string) (*common.MockApi, error) {
+	mockApi, found := registry.Get(id)
 	if !found {
-		err := fmt.Errorf("no mockApi with uuid %d found", uuid)
+		err := fmt.Errorf("no mockApi with id '%s' found", id)
 		log.Error(err)
 		return nil, err
 	}
 	return mockApi, nil
 }
 
-func GetMockApiList() map[uint16]*common.MockApi {
-	return mockApiList
+// returns a copy of the mockApis indexed by id
+func GetMockApiList() map[string]*common.MockApi {
+	return registry.Map()
 }
 
-// TODO: create test
 // look for the mockApi whose name mathes the arg passed id. It
 // returns the mockApi and true/false if found or not
 func GetApiByName(name string) (*common.MockApi, bool) {
-	for _, mockApi := range mockApiList {
-		if mockApi.Name == name {
-			return mockApi, true
-		}
-	}
-	return nil, false
+	_, mockApi, found := registry.GetByName(name)
+	return mockApi, found
 }
 
-// TODO: create test
 // look for the mockApi whose url mathes the arg passed id. It
 // returns the mockApi and true/false if found or not
 func GetApiByUrl(url string) (*common.MockApi, bool) {
-	for _, mockApi := range mockApiList {
-		if mockApi.URL == url {
-			return mockApi, true
-		}
+	_, mockApi, found := registry.GetByUrl(url)
+	return mockApi, found
+}
+
+// look for the mockApi whose url is the given one, leading and trailing slashes
+// aside. It returns its id, the mockApi and true/false if found or not
+func FindApiByUrl(url string) (string, *common.MockApi, bool) {
+	return registry.GetByUrl(url)
+}
+
+// PutMockApi serves the mockApi just stored straight away, without waiting for
+// the folder watcher to detect the change
+func PutMockApi(id string, mockApi *common.MockApi) {
+	storeMockApi(id, mockApi)
+}
+
+// ValidateMockApi checks the body of a request adding or modifying a mockApi
+// without storing it: on top of the checks of the store, the name and the url
+// must not be used by another mockApi. The mockApi with the id of the body is
+// the one being modified. It returns the list of the errors found
+func ValidateMockApi(body []byte) []mockapifilepkg.ValidationError {
+	mockApi, errs := mockapifilepkg.ValidateMockApi(body)
+	if mockApi == nil {
+		return errs
 	}
-	return nil, false
+	if id, _, found := registry.GetByName(mockApi.Name); found && id != mockApi.ID {
+		errs = append(errs, mockapifilepkg.ValidationError{Field: "name", Rule: "unique", Message: fmt.Sprintf("found another mockApi with the same name '%s'", mockApi.Name)})
+	}
+	if id, _, found := registry.GetByUrl(mockApi.URL); found && id != mockApi.ID {
+		errs = append(errs, mockapifilepkg.ValidationError{Field: "url", Rule: "unique", Message: fmt.Sprintf("found another mockApi with the same URL '%s'", mockApi.URL)})
+	}
+	return errs
+}
+
+// look for the mockApi whose url pattern matches the requested path. When
+// several patterns match, the most specific one wins. It returns the id and
+// the mockApi, the parameters captured from the path and true/false if found
+// or not
+func MatchApiByPath(path string) (string, *common.MockApi, map[string]string, bool) {
+	return registry.Match(path)
 }
 
 func observeFolder(closeAll chan bool, wg *sync.WaitGroup) {
@@ -112,13 +149,13 @@ func observeFolder(closeAll chan bool, wg *sync.WaitGroup) {
 		log.Error("the mock API folder has not been set-up")
 		return
 	} else {
-		err := watcher.Add(folderPath)
+		err := watchFolderTree(watcher, folderPath)
 		if err != nil {
 			log.Error("could add folder to the watcher: ", err)
 			return
 		}
 	}
-	log.Info("started watching path ", folderPath)
+	log.Info("started watching path ", folderPath, " and its subfolders")
 	defer stopObserving(watcher)
 detectingCycle:
 	for {
@@ -128,20 +165,41 @@ detectingCycle:
 				log.Error("returned not ok from watcher Events")
 				return
 			}
+			relPath, err := filepath.Rel(folderPath, event.Name)
+			if err != nil {
+				log.Errorf("error while getting the path of %s in the mock api folder: %s", event.Name, err)
+				continue
+			}
+			relPath = filepath.ToSlash(relPath)
 			fileName := path.Base(event.Name)
-			// we are interested in modifications to the *.json files
-			if !strings.HasSuffix(fileName, ".json") {
+			// new subfolders are watched as well
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if !mockapifilepkg.IgnoredFolder(fileName) {
+						log.Debug("new subfolder detected in the folder: ", relPath)
+						detectedNewFolder(watcher, relPath)
+					}
+					continue
+				}
+			}
+			// we are interested in modifications to the *.json and *.yaml files
+			if !mockapifilepkg.IsMockApiFile(fileName) {
+				// removed or moved subfolder
+				if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+					detectedRemovedFolder(relPath)
+				}
 				continue
 			}
-			// any modification to the api file
-			if event.Has(fsnotify.Write) {
-				log.Debug("modified json detected in the folder: ", fileName)
-				detectedModifiedMockApi(fileName)
+			// any modification to the api file. The files written atomically
+			// are renamed over the previous ones, which is detected as a creation
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
+				log.Debug("modified mock api file detected in the folder: ", relPath)
+				detectedModifiedMockApi(relPath)
 			}
-			// removed api file
-			if event.Has(fsnotify.Remove) {
-				log.Debug("removed json detected in the folder: ", fileName)
-				detectedRemovedMockApi(fileName)
+			// removed or moved api file
+			if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				log.Debug("removed mock api file detected in the folder: ", relPath)
+				detectedRemovedMockApi(relPath)
 			}
 		case err, ok := <-watcher.Errors:
 			if !ok {
@@ -156,6 +214,27 @@ detectingCycle:
 	}
 }
 
+// add the folder and its subfolders to the watcher
+func watchFolderTree(watcher *fsnotify.Watcher, dir string) error {
+	if err := watcher.Add(dir); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || mockapifilepkg.IgnoredFolder(entry.Name()) {
+			continue
+		}
+		subfolder := filepath.Join(dir, entry.Name())
+		if err := watchFolderTree(watcher, subfolder); err != nil {
+			log.Errorf("could not watch the subfolder %s: %s", subfolder, err)
+		}
+	}
+	return nil
+}
+
 func stopObserving(watcher *fsnotify.Watcher) {
 	if watcher != nil {
 		err := watcher.Close()
@@ -187,147 +266,172 @@ pollingCycle:
 			break pollingCycle
 		default:
 			time.Sleep(time.Duration(pollerInterval) * time.Second) // poll each 'config.GetPollingInterval()' seconds
-			if mockApiList, err = mockapifilepkg.LoadAPIsFromFolder(); err != nil {
+			mockApis, err := mockapifilepkg.LoadAPIsFromFolder()
+			if err != nil {
 				log.Error("error while loading the stored APIs: ", err)
+				continue
 			}
+			registry.Replace(mockApis)
+			setSources(mockapifilepkg.MockApiSources())
 		}
 	}
 }
 
-func detectedModifiedMockApi(fileName string) {
+// function called once a mock api file of the folder, or of a subfolder, has
+// been modified. The file may hold a list of mockApis
+func detectedModifiedMockApi(relPath string) {
 
 	// open file
-	jsonFile, err := os.Open(folderPath + fileName)
+	jsonFile, err := os.Open(filepath.Join(folderPath, relPath))
 	if err != nil {
-		log.Errorf("error while opening the file %s: %s", fileName, err)
+		log.Errorf("error while opening the file %s: %s", relPath, err)
 		return
 	}
+	defer jsonFile.Close()
 
 	// read content
 	byteValue, err := io.ReadAll(jsonFile)
 	if err != nil {
-		log.Errorf("error while reading the file %s: %s", fileName, err)
+		log.Errorf("error while reading the file %s: %s", relPath, err)
 		return
 	}
 
-	// unmarshal content
-	var mockApi common.MockApi
-	err = json.Unmarshal(byteValue, &mockApi)
-	if err != nil {
-		log.Errorf("error while unmarshaling the json file %s into the struct: %s", fileName, err)
+	// the files created empty are loaded once written
+	if len(byteValue) == 0 {
+		log.Debugf("the file %s is empty", relPath)
 		return
 	}
 
-	// validate structure
-	vtor := validator.New(validator.WithRequiredStructEnabled())
-	err = vtor.Struct(mockApi)
+	// decode, validate and check content
+	mockApis, err := mockapifilepkg.DecodeMockApiFile(folderPath, relPath, byteValue)
 	if err != nil {
-		log.Errorf("invalid mock api saved in the json file %s into the struct: %s", fileName, err)
+		log.Errorf("invalid mock api saved in the file %s: %s", relPath, err)
 		return
 	}
 
-	// parse uuid into a uint16
-	uuidString, found := strings.CutSuffix(fileName, ".json")
-	if !found {
-		log.Error("suffix '.json' not found")
-		return
+	// the mockApis no longer listed in the file are removed
+	for _, id := range updateSources(relPath, mockApis) {
+		removeMockApi(id)
 	}
-	mockApiUuid64, err := strconv.ParseUint(uuidString, 10, 16)
-	if err != nil {
-		err := fmt.Errorf("error while parsing uuid of the mockApi file '%s' into uint16", fileName)
-		log.Error(err)
+	for id, mockApi := range mockApis {
+		storeMockApi(id, mockApi)
+	}
+}
+
+// add the mockApi, or replace the one with the same id, unless another
+// mockApi duplicates its name or url
+func storeMockApi(id string, mockApi *common.MockApi) {
+	if err := registry.Put(id, mockApi); err != nil {
+		log.Errorf("the mockApi '%s' won't be loaded: %s", id, err)
 		return
 	}
-	uuid := uint16(mockApiUuid64)
 
-	// check if mockApi is a new one or not
-	_, found = mockApiList[uuid]
+	// the sequences of the modified mockApi start over
+	resetSequences(mockApi.Name)
 
-	// TODO: add test of this part
-	if found {
-		// this is a modification done on an existing mock Api
+	log.Infof("mockApi '%s' was succesfully modified", id)
+}
 
-		// save previous stage of mockApiList, remove the modified mockApi from
-		// the mockApiList and check if the modified mockApiList contains any
-		// mockApi that duplicates the name or the url of the modified mockApi
-		tmpMockApiList := mockApiList
-		delete(mockApiList, uuid)
+// function called once a mock api file has been removed from the folder
+func detectedRemovedMockApi(relPath string) {
 
-		// check if another mockApi has the same name of the modified mock Api
-		_, found := GetApiByName(mockApi.Name)
-		if found {
-			log.Error("Found another mockApi with the same name of the modified one. The mockApi won't be modifed")
-			mockApiList = tmpMockApiList
-			return
-		}
+	ids := forgetSources(relPath)
+	if len(ids) == 0 {
+		log.Infof("no mockApi loaded from the file %s. Probably already removed it", relPath)
+		return
+	}
 
-		// check if another mockApi has the same url of the modified mock Api
-		_, found = GetApiByUrl(mockApi.URL)
-		if found {
-			log.Error("Found another mockApi with the same URL of the modified one. The mockApi won't be modifed")
-			mockApiList = tmpMockApiList
-			return
-		}
+	for _, id := range ids {
+		removeMockApi(id)
+	}
+}
 
-		// add the new mock to the list
-		mockApiList[uuid] = &mockApi
+// function called once a subfolder has been created: it is watched and its
+// mock api files are loaded
+func detectedNewFolder(watcher *fsnotify.Watcher, relPath string) {
+	if err := watchFolderTree(watcher, filepath.Join(folderPath, relPath)); err != nil {
+		log.Errorf("could not watch the subfolder %s: %s", relPath, err)
+		return
+	}
+	files, err := mockapifilepkg.ListMockApiFiles(folderPath, relPath)
+	if err != nil {
+		log.Errorf("error while getting entries from the subfolder %s: %s", relPath, err)
+		return
+	}
+	for _, file := range files {
+		detectedModifiedMockApi(file)
+	}
+}
 
-		log.Infof("mockApi %d was succesfully loaded", uuid)
+// function called once a subfolder has been removed: the mockApis of its files
+// are removed
+func detectedRemovedFolder(relPath string) {
+	for _, id := range forgetSources(relPath) {
+		removeMockApi(id)
+	}
+}
 
-	} else {
-		// this is a new mock Api
+func setSources(newSources map[string]string) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	sources = newSources
+}
 
-		// check if another mockApi has the same name or url
-		_, found := GetApiByName(mockApi.Name)
-		if found {
-			log.Error("found another mockApi with the same name. the new mockApi won't be loaded")
-			return
+// record the mockApis loaded from the file, returning the ids of the ones
+// previously loaded from it and no longer there. The mockApis sharing the id
+// of a mockApi of another file are dropped
+func updateSources(relPath string, mockApis map[string]*common.MockApi) []string {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	removed := make([]string, 0)
+	for id, source := range sources {
+		if _, found := mockApis[id]; source == relPath && !found {
+			removed = append(removed, id)
+			delete(sources, id)
 		}
-
-		_, found = GetApiByUrl(mockApi.URL)
-		if found {
-			log.Error("found another mockApi with the same URL. the new mockApi won't be loaded")
-			return
+	}
+	for id := range mockApis {
+		if source, found := sources[id]; found && source != relPath {
+			log.Errorf("the mockApi '%s' of the file %s won't be loaded: same id of a mockApi of the file %s", id, relPath, source)
+			delete(mockApis, id)
+			continue
 		}
+		sources[id] = relPath
 	}
-
-	// add it to the list
-	mockApiList[uuid] = &mockApi
-
-	log.Infof("mockApi %d was succesfully modified", uuid)
+	return removed
 }
 
-// function called once a json mock api file has been removed from the folder
-func detectedRemovedMockApi(fileName string) {
-
-	// parse uuid into a uint16
-	uuidString, found := strings.CutSuffix(fileName, ".json")
-	if !found {
-		log.Error("suffix '.json' not found")
-		return
-	}
-	// parse uuid into a uint16
-	mockApiUuid64, err := strconv.ParseUint(uuidString, 10, 16)
-	if err != nil {
-		err := fmt.Errorf("error while parsing uuid of the mockApi file '%s' into uint16", fileName)
-		log.Error(err)
+// forget the mockApis loaded from the file or from the files of the subfolder,
+// returning their ids
+func forgetSources(relPath string) []string {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	forgotten := make([]string, 0)
+	for id, source := range sources {
+		if source == relPath || strings.HasPrefix(source, relPath+"/") {
+			forgotten = append(forgotten, id)
+			delete(sources, id)
+		}
 	}
-	uuid := uint16(mockApiUuid64)
+	return forgotten
+}
 
-	// search for the mockApi
-	mockApi, found := mockApiList[uuid]
+// delete the mockApi from the list
+func removeMockApi(id string) {
+	mockApi, found := registry.Remove(id)
 	if !found {
-		log.Info("mock api named '", fileName, "' not found in the list. Probably already removed it")
+		log.Infof("mock api '%s' not found in the list. Probably already removed it", id)
 		return
 	}
+	log.Infof("mock api named %s was successfully removed", mockApi.Name)
+}
 
-	// delete the mockApi from the list
-	delete(mockApiList, uuid)
-
-	// check it was removed from the list
-	if _, ok := mockApiList[uuid]; ok {
-		log.Errorf("mock api named %s was not removed", mockApi.Name)
-	} else {
-		log.Infof("mock api named %s was successfully removed", mockApi.Name)
+// function notified of the changes made through the stores which are not
+// observed by the folder watcher
+func storeChanged(id string, mockApi *common.MockApi) {
+	if mockApi == nil {
+		removeMockApi(id)
+		return
 	}
+	storeMockApi(id, mockApi)
 }
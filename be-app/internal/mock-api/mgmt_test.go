@@ -2,9 +2,11 @@ package mockapipkg
 
 import (
 	"dynamocker/internal/common"
+	requestmatcherpkg "dynamocker/internal/request-matcher"
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"sync"
@@ -18,9 +20,19 @@ const observeFolderWitingTimeMilliseconds = 200
 
 // reset package map and folderPath variable
 func reset(t *testing.T) {
-	mockApiList = make(map[uint16]*common.MockApi)
+	registry = NewRegistry()
 	folderPath = ""
-	assert.Equal(t, 0, len(mockApiList))
+	ResetAllScenarios()
+	assert.Equal(t, 0, registry.Len())
+}
+
+// load the mockApis in the registry, using their index as id
+func load(mockApis ...*common.MockApi) {
+	list := make(map[string]*common.MockApi)
+	for i, mockApi := range mockApis {
+		list[fmt.Sprint(i)] = mockApi
+	}
+	registry.Replace(list)
 }
 
 func dummyMockApi(t *testing.T) common.MockApi {
@@ -72,10 +84,10 @@ func dummyMockApiArray(t *testing.T) []*common.MockApi {
 
 // write a dummy mock api file to the Temp folder. The temp folder
 // comes from os package
-func writeDummyMockApiFile(t *testing.T) (uint16, *os.File, common.MockApi) {
+func writeDummyMockApiFile(t *testing.T) (string, *os.File, common.MockApi) {
 	mockApi := dummyMockApi(t)
-	uuid := uint16(rand.Intn(1000))
-	filename := fmt.Sprintf("%d", uuid) + ".json"
+	id := fmt.Sprintf("dummy-mock-api-%d", rand.Intn(1000))
+	filename := id + ".json"
 	filePath := os.TempDir() + "/" + filename
 	file, err := os.Create(filePath)
 	if err != nil {
@@ -88,7 +100,8 @@ func writeDummyMockApiFile(t *testing.T) (uint16, *os.File, common.MockApi) {
 		file.Close()
 		t.Fatal("malformed string modification")
 	}
-	mockApi.Name = fmt.Sprintf("dummy-mock-api-%d", uuid)
+	mockApi.ID = id
+	mockApi.Name = id
 	data, err := json.Marshal(mockApi)
 	if err != nil {
 		file.Close()
@@ -98,7 +111,7 @@ func writeDummyMockApiFile(t *testing.T) (uint16, *os.File, common.MockApi) {
 		file.Close()
 		t.Fatalf("error while writing dummy mock api to file :%s", err)
 	}
-	return uuid, file, mockApi
+	return id, file, mockApi
 }
 
 // check that the closeChannel works
@@ -151,9 +164,7 @@ func TestGetAPIs(t *testing.T) {
 
 	// add apis to the map and check length
 	mockApis := dummyMockApiArray(t)
-	for i := 0; i < 5; i++ {
-		mockApiList[uint16(i)] = mockApis[i]
-	}
+	load(mockApis...)
 	assert.Equal(t, 5, len(GetMockAPIs()))
 
 	// remove apis from the map and check it is empty
@@ -171,7 +182,7 @@ func TestGetAPI(t *testing.T) {
 
 	// add mock api to the map
 	mockApi := dummyMockApi(t)
-	mockApiList[0] = &mockApi
+	load(&mockApi)
 
 	// check the get works
 	res, found := GetApiByName(mockApi.Name)
@@ -179,6 +190,104 @@ func TestGetAPI(t *testing.T) {
 	assert.Equal(t, *res, mockApi)
 }
 
+func TestMatchApiByPath(t *testing.T) {
+	reset(t)
+
+	// no mockApi loaded
+	_, _, _, found := MatchApiByPath("users/42/orders")
+	assert.False(t, found)
+
+	mockApis := make([]*common.MockApi, 0)
+	for _, url := range []string{"users/{id}/orders", "users/{id:[0-9]+}", "users/me", "users/**", "url.com"} {
+		mockApi := dummyMockApi(t)
+		mockApi.URL = url
+		mockApis = append(mockApis, &mockApi)
+	}
+	load(mockApis...)
+
+	tests := []struct {
+		path   string
+		url    string
+		params map[string]string
+	}{
+		{"/users/42/orders", "users/{id}/orders", map[string]string{"id": "42"}},
+		{"users/42", "users/{id:[0-9]+}", map[string]string{"id": "42"}},
+		{"users/me", "users/me", map[string]string{}},
+		{"users/john", "users/**", map[string]string{}},
+		{"users/42/orders/3", "users/**", map[string]string{}},
+		{"url.com", "url.com", map[string]string{}},
+	}
+	for _, test := range tests {
+		_, mockApi, params, found := MatchApiByPath(test.path)
+		assert.True(t, found, test.path)
+		assert.Equal(t, test.url, mockApi.URL, test.path)
+		assert.Equal(t, test.params, params, test.path)
+	}
+
+	_, _, _, found = MatchApiByPath("orders/3")
+	assert.False(t, found)
+}
+
+func TestSelectResponse(t *testing.T) {
+	reset(t)
+
+	mockApi := dummyMockApi(t)
+	mockApi.Scenario = "order"
+	if json.Unmarshal([]byte(`{
+		"sequence": [{"body":{"status":"pending"}},{"body":{"status":"pending"}},{"body":{"status":"done"},"newState":"Completed"}],
+		"candidates": [
+			{"match":{"query":{"id":{"equalTo":"1"}}},"state":"Completed","body":{"status":"archived"}},
+			{"match":{"query":{"id":{"equalTo":"2"}}},"body":{"status":"unknown"}}
+		]
+	}`), &mockApi.Responses.Get) != nil {
+		t.Fatal("error while unmarshaling")
+	}
+	load(&mockApi)
+
+	serve := func(query string) interface{} {
+		r := httptest.NewRequest("GET", "/url?"+query, nil)
+		req, err := requestmatcherpkg.FromHttpRequest(r, "url", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := SelectResponse(&mockApi, mockApi.Responses.Get, req)
+		if response == nil {
+			return nil
+		}
+		return response.Body.(map[string]interface{})["status"]
+	}
+
+	state, found := GetScenarioState("order")
+	assert.True(t, found)
+	assert.Equal(t, common.ScenarioStateStarted, state)
+
+	// the candidate requiring a state is skipped until the scenario reaches it
+	assert.Equal(t, "pending", serve("id=1"))
+	assert.Equal(t, "unknown", serve("id=2"))
+	assert.Equal(t, "pending", serve("id=1"))
+	assert.Equal(t, "done", serve(""))
+	state, _ = GetScenarioState("order")
+	assert.Equal(t, "Completed", state)
+	assert.Equal(t, "archived", serve("id=1"))
+
+	// the last response of the sequence is repeated
+	assert.Equal(t, "done", serve(""))
+
+	// reset the scenario and its sequences
+	ResetScenario("order")
+	state, _ = GetScenarioState("order")
+	assert.Equal(t, common.ScenarioStateStarted, state)
+	assert.Equal(t, "pending", serve("id=1"))
+
+	// set the state manually
+	SetScenarioState("order", "Completed")
+	assert.Equal(t, "archived", serve("id=1"))
+	assert.Equal(t, map[string]string{"order": "Completed"}, GetScenarioStates())
+
+	_, found = GetScenarioState("unknown")
+	assert.False(t, found)
+}
+
 func TestObserveFolderNotSet(t *testing.T) {
 	reset(t)
 
@@ -299,7 +408,7 @@ func TestObserveFolderNoJson(t *testing.T) {
 		t.Fatalf("error while writing dummy mock api to file :%s", err)
 	}
 	// check the mock api has not been loaded
-	assert.Empty(t, mockApiList)
+	assert.Zero(t, registry.Len())
 }
 
 func TestObserveFolder(t *testing.T) {
@@ -320,8 +429,8 @@ func TestObserveFolder(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	// write proper mock api file
-	uuid, file, mockApi := writeDummyMockApiFile(t)
-	filePath := folderPath + fmt.Sprintf("%d", uuid) + ".json"
+	id, file, mockApi := writeDummyMockApiFile(t)
+	filePath := folderPath + id + ".json"
 	defer func() {
 		if _, err := os.Stat(filePath); err == nil {
 			os.Remove(filePath)
@@ -331,10 +440,10 @@ func TestObserveFolder(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	// check the mock api has been loaded
-	assert.Equal(t, 1, len(mockApiList))
+	assert.Equal(t, 1, registry.Len())
 	retrievedMockApi, found := GetApiByName(mockApi.Name)
 	assert.True(t, found)
-	_, found = mockApiList[uuid]
+	_, found = registry.Get(id)
 	assert.True(t, found)
 	assert.Equal(t, mockApi.Name, retrievedMockApi.Name)
 	assert.Equal(t, mockApi.URL, retrievedMockApi.URL)
@@ -345,10 +454,10 @@ func TestObserveFolder(t *testing.T) {
 
 	// modify the file
 	mockApi.URL = "newUrl.com"
-	mockApi.Responses.Get = &map[string]interface{}{}
-	mockApi.Responses.Post = &map[string]interface{}{}
-	mockApi.Responses.Patch = &map[string]interface{}{}
-	mockApi.Responses.Delete = &map[string]interface{}{}
+	mockApi.Responses.Get = &common.MethodResponse{}
+	mockApi.Responses.Post = &common.MethodResponse{}
+	mockApi.Responses.Patch = &common.MethodResponse{}
+	mockApi.Responses.Delete = &common.MethodResponse{}
 	if json.Unmarshal([]byte(`{"new_delete":"body"}`), &mockApi.Responses.Delete) != nil {
 		t.Fatal("error while unmarshalling")
 	}
@@ -384,7 +493,7 @@ func TestObserveFolder(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	// check the mock api has been modified
-	assert.Equal(t, 1, len(mockApiList))
+	assert.Equal(t, 1, registry.Len())
 	retrievedMockApi, found = GetApiByName(mockApi.Name)
 	assert.True(t, found)
 	assert.Equal(t, mockApi.Name, retrievedMockApi.Name)
@@ -401,10 +510,178 @@ func TestObserveFolder(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	// check the mock api has been removed
-	assert.Equal(t, 0, len(mockApiList))
-	_, found = mockApiList[uuid]
+	assert.Equal(t, 0, registry.Len())
+	_, found = registry.Get(id)
+	assert.False(t, found)
+
+}
+
+func TestObserveFolderYaml(t *testing.T) {
+	reset(t)
+
+	// set mock api folder as a temp folder
+	folderPath = os.TempDir() + "/"
+
+	// make channel and waiting group
+	closeCh := make(chan bool)
+	var wg sync.WaitGroup
+
+	// start observing
+	wg.Add(1)
+	go observeFolder(closeCh, &wg)
+	defer close(closeCh)
+
+	time.Sleep(100 * time.Millisecond)
+
+	// write a yaml mock api file
+	filePath := folderPath + "yaml-mock-api.yml"
+	defer os.Remove(filePath)
+	mockApi := "name: yaml-mock-api\nurl: yaml-url\nresponses:\n  get:\n    body: |\n      multi\n      line\n"
+	if err := os.WriteFile(filePath, []byte(mockApi), 0644); err != nil {
+		t.Fatalf("error while writing the yaml file: %s", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	// check the mock api has been loaded
+	retrievedMockApi, found := registry.Get("yaml-mock-api")
+	if !found {
+		t.Fatal("the yaml mock api was not loaded")
+	}
+	assert.Equal(t, "yaml-mock-api", retrievedMockApi.Name)
+	assert.Equal(t, "multi\nline\n", retrievedMockApi.Responses.Get.Body)
+
+	// remove file
+	os.Remove(filePath)
+
+	time.Sleep(100 * time.Millisecond)
+
+	// check the mock api has been removed
+	_, found = registry.Get("yaml-mock-api")
 	assert.False(t, found)
+}
 
+func TestObserveAtomicWrite(t *testing.T) {
+	reset(t)
+
+	// set mock api folder as a temp folder
+	folderPath = t.TempDir() + "/"
+
+	// make channel and waiting group
+	closeCh := make(chan bool)
+	var wg sync.WaitGroup
+
+	// start observing
+	wg.Add(1)
+	go observeFolder(closeCh, &wg)
+	defer close(closeCh)
+
+	time.Sleep(100 * time.Millisecond)
+
+	// the file is written to a temporary file, renamed over the mock api file
+	write := func(url string) {
+		tmpPath := folderPath + ".users.json.tmp"
+		mockApi := fmt.Sprintf(`{"name":"users","url":"%s","responses":{"get":{"status":204}}}`, url)
+		if err := os.WriteFile(tmpPath, []byte(mockApi), 0644); err != nil {
+			t.Fatalf("error while writing the temporary file: %s", err)
+		}
+		if err := os.Rename(tmpPath, folderPath+"users.json"); err != nil {
+			t.Fatalf("error while renaming the temporary file: %s", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	write("users")
+	retrievedMockApi, found := registry.Get("users")
+	if !found {
+		t.Fatal("the mock api was not loaded")
+	}
+	assert.Equal(t, "users", retrievedMockApi.URL)
+
+	// replaced without being removed
+	write("users-v2")
+	retrievedMockApi, found = registry.Get("users")
+	assert.True(t, found)
+	assert.Equal(t, "users-v2", retrievedMockApi.URL)
+
+	// moving the file out of the folder removes the mock api
+	if err := os.Rename(folderPath+"users.json", t.TempDir()+"/users.json"); err != nil {
+		t.Fatalf("error while moving the file: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	_, found = registry.Get("users")
+	assert.False(t, found)
+}
+
+func TestObserveFolderTree(t *testing.T) {
+	reset(t)
+
+	// set mock api folder as a temp folder, with a subfolder
+	folderPath = t.TempDir() + "/"
+	if err := os.Mkdir(folderPath+"users", 0755); err != nil {
+		t.Fatalf("error while creating the subfolder: %s", err)
+	}
+
+	// make channel and waiting group
+	closeCh := make(chan bool)
+	var wg sync.WaitGroup
+
+	// start observing
+	wg.Add(1)
+	go observeFolder(closeCh, &wg)
+	defer close(closeCh)
+
+	time.Sleep(100 * time.Millisecond)
+
+	// a file of the subfolder holding a list of mock apis
+	list := `[{"name":"list-users","url":"users","responses":{"get":{"body":[]}}},{"name":"get-user","url":"users/{id}","responses":{"get":{"body":{}}}}]`
+	if err := os.WriteFile(folderPath+"users/mocks.json", []byte(list), 0644); err != nil {
+		t.Fatalf("error while writing the list file: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 2, registry.Len())
+	_, found := GetApiByName("get-user")
+	assert.True(t, found)
+
+	// the mock apis removed from the list are removed
+	list = `[{"name":"list-users","url":"users","responses":{"get":{"body":[]}}}]`
+	if err := os.WriteFile(folderPath+"users/mocks.json", []byte(list), 0644); err != nil {
+		t.Fatalf("error while writing the list file: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, registry.Len())
+	_, found = GetApiByName("get-user")
+	assert.False(t, found)
+
+	// new subfolders are watched, the files already in them are loaded
+	if err := os.MkdirAll(folderPath+"orders/v2", 0755); err != nil {
+		t.Fatalf("error while creating the subfolders: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(folderPath+"orders/v2/orders.yaml", []byte("name: orders\nurl: orders\nresponses:\n  get:\n    status: 204\n"), 0644); err != nil {
+		t.Fatalf("error while writing the yaml file: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	_, found = GetApiByName("orders")
+	assert.True(t, found)
+
+	// the mock apis sharing the id of a mock api of another file are skipped
+	if err := os.WriteFile(folderPath+"orders/copy.json", []byte(`{"id":"orders","name":"copy","url":"copy","responses":{"get":{"status":204}}}`), 0644); err != nil {
+		t.Fatalf("error while writing the json file: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	_, found = GetApiByName("copy")
+	assert.False(t, found)
+	os.Remove(folderPath + "orders/copy.json")
+	time.Sleep(100 * time.Millisecond)
+	_, found = GetApiByName("orders")
+	assert.True(t, found)
+
+	// removing a subfolder removes the mock apis of its files
+	os.RemoveAll(folderPath + "users")
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, registry.Len())
+	_, found = GetApiByName("list-users")
+	assert.False(t, found)
 }
 
 func TestStopObserving(t *testing.T) {
@@ -425,8 +702,8 @@ func TestStopObserving(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	// write proper mock api file
-	uuid, file, _ := writeDummyMockApiFile(t)
-	filePath := folderPath + fmt.Sprintf("%d", uuid) + ".json"
+	id, file, _ := writeDummyMockApiFile(t)
+	filePath := folderPath + id + ".json"
 	defer func() {
 		file.Close()
 		if _, err := os.Stat(filePath); err == nil {
@@ -437,7 +714,7 @@ func TestStopObserving(t *testing.T) {
 	time.Sleep(200 * time.Millisecond)
 
 	// check the mock api has been loaded
-	assert.Equal(t, 1, len(mockApiList))
+	assert.Equal(t, 1, registry.Len())
 
 	// stop observing goroutine
 	closeCh <- true
@@ -445,8 +722,8 @@ func TestStopObserving(t *testing.T) {
 	// let goroutine stop
 	time.Sleep(100 * time.Millisecond)
 
-	otherUuid, otherFile, _ := writeDummyMockApiFile(t)
-	otherFilePath := folderPath + fmt.Sprintf("%d", otherUuid) + ".json"
+	otherId, otherFile, _ := writeDummyMockApiFile(t)
+	otherFilePath := folderPath + otherId + ".json"
 	defer func() {
 		otherFile.Close()
 		os.Remove(otherFilePath)
@@ -454,8 +731,8 @@ func TestStopObserving(t *testing.T) {
 
 	// this file should not have been loaded by the observing goroutine and it
 	// can be double checked by checking that the new mock api has not been loaded
-	assert.Equal(t, 1, len(mockApiList))
-	_, found := mockApiList[otherUuid]
+	assert.Equal(t, 1, registry.Len())
+	_, found := registry.Get(otherId)
 	assert.False(t, found)
 
 }
@@ -467,7 +744,7 @@ func TestStopObserving(t *testing.T) {
 // 	// add mock api to the map
 // 	uuid := generateUuid()
 // 	mockApi := dummyMockApi(t)
-// 	mockApiList[uuid] = &mockApi
+// 	registry.Put(uuid, &mockApi)
 
 // 	assert.True(t, reflect.DeepEqual(mockApi, mockApi))
 
@@ -0,0 +1,184 @@
+package mockapipkg
+
+import (
+	"dynamocker/internal/common"
+	urlpatternpkg "dynamocker/internal/url-pattern"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Registry holds the loaded MockApis, indexed by id, name and url. Readers
+// access an immutable snapshot without locking, while writers build a new
+// snapshot (copy-on-write) one at a time. The MockApis stored in the registry
+// must not be modified.
+type Registry struct {
+	writeMu  sync.Mutex
+	snapshot atomic.Pointer[registrySnapshot]
+}
+
+type registrySnapshot struct {
+	byId   map[string]*common.MockApi
+	byName map[string]string
+	// indexed by the key of the url pattern, so that the urls matching the
+	// same paths (e.g. 'users' and '/users/') share the entry
+	byUrl map[string]string
+	// compiled url pattern of each MockApi, the invalid ones are missing
+	patterns map[string]*urlpatternpkg.Pattern
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	registry := &Registry{}
+	registry.snapshot.Store(newSnapshot(nil))
+	return registry
+}
+
+// build the indexes of the MockApis. If several MockApis share the same name
+// or url, the index points to the one with the lowest id
+func newSnapshot(mockApis map[string]*common.MockApi) *registrySnapshot {
+	snapshot := &registrySnapshot{
+		byId:     make(map[string]*common.MockApi, len(mockApis)),
+		byName:   make(map[string]string, len(mockApis)),
+		byUrl:    make(map[string]string, len(mockApis)),
+		patterns: make(map[string]*urlpatternpkg.Pattern, len(mockApis)),
+	}
+	ids := make([]string, 0, len(mockApis))
+	for id := range mockApis {
+		ids = append(ids, id)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	for _, id := range ids {
+		mockApi := mockApis[id]
+		snapshot.byId[id] = mockApi
+		snapshot.byName[mockApi.Name] = id
+		pattern, err := urlpatternpkg.Compile(mockApi.URL)
+		if err != nil {
+			log.Errorf("invalid url of the mockApi '%s': %s", mockApi.Name, err)
+			snapshot.byUrl[urlpatternpkg.Key(mockApi.URL)] = id
+			continue
+		}
+		snapshot.byUrl[pattern.Key()] = id
+		snapshot.patterns[id] = pattern
+	}
+	return snapshot
+}
+
+// Get returns the MockApi with the given id
+func (r *Registry) Get(id string) (*common.MockApi, bool) {
+	mockApi, found := r.snapshot.Load().byId[id]
+	return mockApi, found
+}
+
+// GetByName returns the id and the MockApi with the given name
+func (r *Registry) GetByName(name string) (string, *common.MockApi, bool) {
+	snapshot := r.snapshot.Load()
+	id, found := snapshot.byName[name]
+	if !found {
+		return "", nil, false
+	}
+	return id, snapshot.byId[id], true
+}
+
+// GetByUrl returns the id and the MockApi with the given url, or with an url
+// matching the same paths
+func (r *Registry) GetByUrl(url string) (string, *common.MockApi, bool) {
+	snapshot := r.snapshot.Load()
+	id, found := snapshot.byUrl[urlpatternpkg.Key(url)]
+	if !found {
+		return "", nil, false
+	}
+	return id, snapshot.byId[id], true
+}
+
+// Match returns the id of the MockApi whose url pattern matches the path, the
+// MockApi and the parameters captured from the path. When several patterns
+// match, the most specific one wins
+func (r *Registry) Match(path string) (string, *common.MockApi, map[string]string, bool) {
+	snapshot := r.snapshot.Load()
+	var bestId string
+	var bestPattern *urlpatternpkg.Pattern
+	var bestParams map[string]string
+	for id, pattern := range snapshot.patterns {
+		params, match := pattern.Match(path)
+		if !match {
+			continue
+		}
+		if bestPattern == nil || pattern.MoreSpecific(bestPattern) {
+			bestId, bestPattern, bestParams = id, pattern, params
+		}
+	}
+	if bestPattern == nil {
+		return "", nil, nil, false
+	}
+	return bestId, snapshot.byId[bestId], bestParams, true
+}
+
+// Map returns a copy of the MockApis indexed by id
+func (r *Registry) Map() map[string]*common.MockApi {
+	snapshot := r.snapshot.Load()
+	mockApis := make(map[string]*common.MockApi, len(snapshot.byId))
+	for id, mockApi := range snapshot.byId {
+		mockApis[id] = mockApi
+	}
+	return mockApis
+}
+
+// List returns the MockApis
+func (r *Registry) List() []*common.MockApi {
+	snapshot := r.snapshot.Load()
+	mockApis := make([]*common.MockApi, 0, len(snapshot.byId))
+	for _, mockApi := range snapshot.byId {
+		mockApis = append(mockApis, mockApi)
+	}
+	return mockApis
+}
+
+// Len returns the number of MockApis
+func (r *Registry) Len() int {
+	return len(r.snapshot.Load().byId)
+}
+
+// Replace replaces all the MockApis, e.g. with the ones loaded from the folder
+func (r *Registry) Replace(mockApis map[string]*common.MockApi) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	r.snapshot.Store(newSnapshot(mockApis))
+}
+
+// Put adds the MockApi, or replaces the one with the same id. It fails if
+// another MockApi has the same name or the same url
+func (r *Registry) Put(id string, mockApi *common.MockApi) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	snapshot := r.snapshot.Load()
+	if other, found := snapshot.byName[mockApi.Name]; found && other != id {
+		return fmt.Errorf("found another mockApi with the same name '%s'", mockApi.Name)
+	}
+	if other, found := snapshot.byUrl[urlpatternpkg.Key(mockApi.URL)]; found && other != id {
+		return fmt.Errorf("found another mockApi with the same URL '%s'", mockApi.URL)
+	}
+	mockApis := r.Map()
+	mockApis[id] = mockApi
+	r.snapshot.Store(newSnapshot(mockApis))
+	return nil
+}
+
+// Remove removes the MockApi with the given id and returns it
+func (r *Registry) Remove(id string) (*common.MockApi, bool) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	mockApis := r.Map()
+	mockApi, found := mockApis[id]
+	if !found {
+		return nil, false
+	}
+	delete(mockApis, id)
+	r.snapshot.Store(newSnapshot(mockApis))
+	return mockApi, true
+}
@@ -0,0 +1,137 @@
+package mockapipkg
+
+import (
+	"dynamocker/internal/common"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	assert.Equal(t, 0, registry.Len())
+	_, _, found := registry.GetByName("users")
+	assert.False(t, found)
+
+	users := &common.MockApi{Name: "users", URL: "users"}
+	orders := &common.MockApi{Name: "orders", URL: "orders"}
+	assert.Nil(t, registry.Put("users", users))
+	assert.Nil(t, registry.Put("orders", orders))
+	assert.Equal(t, 2, registry.Len())
+
+	id, mockApi, found := registry.GetByName("orders")
+	assert.True(t, found)
+	assert.Equal(t, "orders", id)
+	assert.Equal(t, orders, mockApi)
+	id, _, found = registry.GetByUrl("users")
+	assert.True(t, found)
+	assert.Equal(t, "users", id)
+
+	// duplicates of other mockApis are rejected
+	assert.EqualError(t, registry.Put("other", &common.MockApi{Name: "users", URL: "other"}), "found another mockApi with the same name 'users'")
+	assert.EqualError(t, registry.Put("other", &common.MockApi{Name: "other", URL: "orders"}), "found another mockApi with the same URL 'orders'")
+	assert.EqualError(t, registry.Put("users", &common.MockApi{Name: "users", URL: "orders"}), "found another mockApi with the same URL 'orders'")
+
+	// the urls matching the same paths are the same url
+	assert.EqualError(t, registry.Put("other", &common.MockApi{Name: "other", URL: "/orders/"}), "found another mockApi with the same URL '/orders/'")
+	_, mockApi, found = registry.GetByUrl("/users")
+	assert.True(t, found)
+	assert.Equal(t, users, mockApi)
+
+	// the same id is replaced and the indexes updated
+	renamed := &common.MockApi{Name: "customers", URL: "customers"}
+	assert.Nil(t, registry.Put("users", renamed))
+	_, _, found = registry.GetByName("users")
+	assert.False(t, found)
+	_, mockApi, found = registry.GetByUrl("customers")
+	assert.True(t, found)
+	assert.Equal(t, renamed, mockApi)
+
+	removed, found := registry.Remove("users")
+	assert.True(t, found)
+	assert.Equal(t, renamed, removed)
+	_, found = registry.Remove("users")
+	assert.False(t, found)
+	_, _, found = registry.GetByName("customers")
+	assert.False(t, found)
+
+	// the copies returned don't change the registry
+	registry.Map()["other"] = users
+	assert.Equal(t, 1, registry.Len())
+
+	// replaced from the folder: duplicates are indexed by the lowest id
+	registry.Replace(map[string]*common.MockApi{"users-b": users, "users-a": {Name: "users", URL: "users-bis"}})
+	id, _, _ = registry.GetByName("users")
+	assert.Equal(t, "users-a", id)
+	assert.Equal(t, 2, len(registry.List()))
+}
+
+func TestRegistryMatch(t *testing.T) {
+	registry := NewRegistry()
+	registry.Replace(map[string]*common.MockApi{
+		"user":    {Name: "user", URL: "users/{id}"},
+		"admin":   {Name: "admin", URL: "users/admin"},
+		"invalid": {Name: "invalid", URL: "users/{id"},
+	})
+
+	// the most specific pattern wins
+	id, mockApi, params, found := registry.Match("/users/42")
+	assert.True(t, found)
+	assert.Equal(t, "user", id)
+	assert.Equal(t, "user", mockApi.Name)
+	assert.Equal(t, map[string]string{"id": "42"}, params)
+	id, _, _, found = registry.Match("users/admin")
+	assert.True(t, found)
+	assert.Equal(t, "admin", id)
+	_, _, _, found = registry.Match("orders")
+	assert.False(t, found)
+
+	// the removed mockApis are no longer matched
+	registry.Remove("user")
+	_, _, _, found = registry.Match("users/42")
+	assert.False(t, found)
+}
+
+func TestRegistryConcurrentAccess(t *testing.T) {
+	registry := NewRegistry()
+	var wg sync.WaitGroup
+	for writer := 0; writer < 4; writer++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				name := fmt.Sprintf("mock-%d", writer*1000+i%10)
+				registry.Put(name, &common.MockApi{Name: name, URL: name})
+				if i%3 == 0 {
+					registry.Remove(name)
+				}
+				if i%50 == 0 {
+					registry.Replace(registry.Map())
+				}
+			}
+		}(writer)
+	}
+	for reader := 0; reader < 4; reader++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				registry.GetByName(fmt.Sprintf("mock-%d", i%10))
+				registry.GetByUrl(fmt.Sprintf("mock-%d", 1000+i%10))
+				for range registry.List() {
+				}
+				registry.Len()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the indexes are consistent with the mockApis
+	for id, mockApi := range registry.Map() {
+		indexed, _, found := registry.GetByName(mockApi.Name)
+		assert.True(t, found)
+		assert.Equal(t, id, indexed)
+	}
+}
@@ -0,0 +1,174 @@
+package mockapipkg
+
+import (
+	"dynamocker/internal/common"
+	requestmatcherpkg "dynamocker/internal/request-matcher"
+	"fmt"
+	"net/http"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// scenarioMu guards the state of the scenarios and the sequence counters, so
+// that selecting a response and moving the scenario to its new state is atomic
+var scenarioMu sync.Mutex
+
+// current state of each scenario, missing scenarios are in the Started state
+var scenarioStates = make(map[string]string)
+
+// number of responses already served by each sequence, by mockApi name
+var sequenceCounters = make(map[string]map[string]int)
+
+// SelectResponse returns the response of the mockApi to be served for the
+// request. The first candidate matching the request and the state of the
+// scenario is served, otherwise the default response. If the selected
+// response is a sequence, the next response of the sequence is served. The
+// scenario moves to the new state of the served response, if any. It returns
+// nil if no response is available.
+//
+// The sequence and the scenario move on as soon as the response is selected,
+// atomically: the response counts as served even if a fault is injected
+// instead, or the client gives up during the delay. HEAD requests are served
+// the GET response, so they move its sequence on as well.
+func SelectResponse(mockApi *common.MockApi, methodResponse *common.MethodResponse, req *requestmatcherpkg.Request) *common.ResponseDef {
+	scenarioMu.Lock()
+	defer scenarioMu.Unlock()
+
+	method := req.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	state := currentState(mockApi.Scenario)
+	var response *common.ResponseDef
+	for i := range methodResponse.Candidates {
+		candidate := &methodResponse.Candidates[i]
+		if candidate.State != "" && (mockApi.Scenario == "" || candidate.State != state) {
+			continue
+		}
+		if requestmatcherpkg.Matches(candidate.Match, req) {
+			key := fmt.Sprintf("%s candidate %d", method, i)
+			response = nextResponse(mockApi.Name, key, &candidate.ResponseDef, candidate.Sequence)
+			break
+		}
+	}
+	if response == nil {
+		key := fmt.Sprintf("%s default", method)
+		response = nextResponse(mockApi.Name, key, &methodResponse.ResponseDef, methodResponse.Sequence)
+	}
+	if response == nil {
+		return nil
+	}
+
+	if response.NewState != "" && mockApi.Scenario != "" {
+		log.Infof("scenario '%s' moved from state '%s' to '%s'", mockApi.Scenario, state, response.NewState)
+		scenarioStates[mockApi.Scenario] = response.NewState
+	}
+	return response
+}
+
+// return the response, or the next one of the sequence if defined
+func nextResponse(mockApiName string, key string, response *common.ResponseDef, sequence common.Sequence) *common.ResponseDef {
+	if len(sequence) == 0 {
+		if response.IsEmpty() {
+			return nil
+		}
+		return response
+	}
+	counters, found := sequenceCounters[mockApiName]
+	if !found {
+		counters = make(map[string]int)
+		sequenceCounters[mockApiName] = counters
+	}
+	index := counters[key]
+	counters[key]++
+	if index >= len(sequence) {
+		index = len(sequence) - 1
+	}
+	return &sequence[index]
+}
+
+func currentState(scenario string) string {
+	if state, found := scenarioStates[scenario]; found {
+		return state
+	}
+	return common.ScenarioStateStarted
+}
+
+// GetScenarioStates returns the current state of all the scenarios, both the
+// ones used by the loaded mockApis and the ones whose state was set
+func GetScenarioStates() map[string]string {
+	scenarioMu.Lock()
+	defer scenarioMu.Unlock()
+
+	states := make(map[string]string)
+	for _, mockApi := range registry.List() {
+		if mockApi.Scenario != "" {
+			states[mockApi.Scenario] = common.ScenarioStateStarted
+		}
+	}
+	for name, state := range scenarioStates {
+		states[name] = state
+	}
+	return states
+}
+
+// GetScenarioState returns the current state of the scenario and false if
+// no mockApi uses it and its state was never set
+func GetScenarioState(scenario string) (string, bool) {
+	scenarioMu.Lock()
+	defer scenarioMu.Unlock()
+
+	if state, found := scenarioStates[scenario]; found {
+		return state, true
+	}
+	for _, mockApi := range registry.List() {
+		if mockApi.Scenario == scenario {
+			return common.ScenarioStateStarted, true
+		}
+	}
+	return "", false
+}
+
+// SetScenarioState moves the scenario to the given state
+func SetScenarioState(scenario string, state string) {
+	scenarioMu.Lock()
+	defer scenarioMu.Unlock()
+
+	scenarioStates[scenario] = state
+	log.Infof("scenario '%s' moved to state '%s'", scenario, state)
+}
+
+// ResetScenario moves the scenario back to the Started state and restarts the
+// sequences of the mockApis using it
+func ResetScenario(scenario string) {
+	scenarioMu.Lock()
+	defer scenarioMu.Unlock()
+
+	delete(scenarioStates, scenario)
+	for _, mockApi := range registry.List() {
+		if mockApi.Scenario == scenario {
+			delete(sequenceCounters, mockApi.Name)
+		}
+	}
+	log.Infof("scenario '%s' has been reset", scenario)
+}
+
+// ResetAllScenarios moves all the scenarios back to the Started state and
+// restarts all the sequences
+func ResetAllScenarios() {
+	scenarioMu.Lock()
+	defer scenarioMu.Unlock()
+
+	scenarioStates = make(map[string]string)
+	sequenceCounters = make(map[string]map[string]int)
+	log.Info("all the scenarios have been reset")
+}
+
+// restart the sequences of the mockApi, e.g. after it has been modified
+func resetSequences(mockApiName string) {
+	scenarioMu.Lock()
+	defer scenarioMu.Unlock()
+
+	delete(sequenceCounters, mockApiName)
+}
@@ -0,0 +1,190 @@
+package openapipkg
+
+import (
+	"dynamocker/internal/common"
+	urlpatternpkg "dynamocker/internal/url-pattern"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"sort"
+)
+
+// FromMockApis describes the mockApis as an OpenAPI 3 document. Every status
+// code served by a method (default response, sequences and candidates) is
+// documented, using the first response serving it as example. The schemas
+// are inferred from the json bodies.
+func FromMockApis(mockApis []*common.MockApi) *Document {
+	doc := Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "dynamocker",
+			Description: "mock APIs served by dynamocker",
+			Version:     "1.0.0",
+		},
+		Paths: make(map[string]*PathItem),
+	}
+
+	// sort by name to have a deterministic result when urls collide
+	sorted := append(make([]*common.MockApi, 0, len(mockApis)), mockApis...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	for _, mockApi := range sorted {
+		pattern, err := urlpatternpkg.Compile(mockApi.URL)
+		if err != nil {
+			continue
+		}
+		template, params := pattern.Template()
+		path := "/" + template
+		item, found := doc.Paths[path]
+		if !found {
+			item = &PathItem{Summary: mockApi.Name, Parameters: pathParameters(params)}
+			doc.Paths[path] = item
+		}
+		for method, response := range mockApi.Responses.ByMethod() {
+			item.setOperation(method, &Operation{
+				OperationId: common.Slug(mockApi.Name + "-" + method),
+				Summary:     mockApi.Name,
+				Responses:   exportResponses(response),
+			})
+		}
+	}
+	return &doc
+}
+
+func pathParameters(params []urlpatternpkg.Param) []*Parameter {
+	parameters := make([]*Parameter, 0, len(params))
+	for _, param := range params {
+		schema := Schema{Type: "string"}
+		if param.Regex != "" {
+			schema.Pattern = "^(?:" + param.Regex + ")$"
+		}
+		parameters = append(parameters, &Parameter{Name: param.Name, In: "path", Required: true, Schema: &schema})
+	}
+	return parameters
+}
+
+// set the operation of the method, unless already defined by another mockApi
+func (p *PathItem) setOperation(method string, operation *Operation) {
+	var target **Operation
+	switch method {
+	case http.MethodGet:
+		target = &p.Get
+	case http.MethodPut:
+		target = &p.Put
+	case http.MethodPost:
+		target = &p.Post
+	case http.MethodDelete:
+		target = &p.Delete
+	case http.MethodOptions:
+		target = &p.Options
+	case http.MethodHead:
+		target = &p.Head
+	case http.MethodPatch:
+		target = &p.Patch
+	default:
+		return
+	}
+	if *target == nil {
+		*target = operation
+	}
+}
+
+// document a response per status code served by the method
+func exportResponses(response *common.MethodResponse) map[string]*Response {
+	defs := make([]common.ResponseDef, 0)
+	if !response.ResponseDef.IsEmpty() {
+		defs = append(defs, response.ResponseDef)
+	}
+	defs = append(defs, response.Sequence...)
+	for _, candidate := range response.Candidates {
+		if !candidate.ResponseDef.IsEmpty() {
+			defs = append(defs, candidate.ResponseDef)
+		}
+		defs = append(defs, candidate.Sequence...)
+	}
+
+	responses := make(map[string]*Response)
+	for i := range defs {
+		code := fmt.Sprint(defs[i].StatusCode())
+		if _, found := responses[code]; !found {
+			responses[code] = exportResponse(&defs[i])
+		}
+	}
+	return responses
+}
+
+func exportResponse(def *common.ResponseDef) *Response {
+	description := http.StatusText(def.StatusCode())
+	if description == "" {
+		description = "mocked response"
+	}
+	response := Response{Description: description}
+
+	for name, value := range def.Headers {
+		if http.CanonicalHeaderKey(name) == "Content-Type" {
+			continue
+		}
+		if response.Headers == nil {
+			response.Headers = make(map[string]*Header)
+		}
+		response.Headers[name] = &Header{Schema: &Schema{Type: "string"}, Example: value}
+	}
+
+	if def.Body == nil {
+		return &response
+	}
+	mediaType, _, err := mime.ParseMediaType(def.ContentType())
+	if err != nil {
+		mediaType = def.ContentType()
+	}
+	content := MediaType{}
+	switch def.BodyType {
+	case common.BodyTypeText:
+		content.Schema = &Schema{Type: "string"}
+		content.Example = def.Body
+	case common.BodyTypeBase64:
+		content.Schema = &Schema{Type: "string", Format: "binary"}
+	default:
+		content.Schema = inferSchema(def.Body)
+		content.Example = def.Body
+	}
+	response.Content = map[string]*MediaType{mediaType: &content}
+	return &response
+}
+
+// inferSchema describes the json value. The items of the arrays are
+// described by their first element
+func inferSchema(value interface{}) *Schema {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		schema := Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for key, item := range v {
+			if property := inferSchema(item); property != nil {
+				schema.Properties[key] = property
+			}
+		}
+		return &schema
+	case []interface{}:
+		schema := Schema{Type: "array", Items: &Schema{}}
+		if len(v) > 0 {
+			if items := inferSchema(v[0]); items != nil {
+				schema.Items = items
+			}
+		}
+		return &schema
+	case string:
+		return &Schema{Type: "string"}
+	case bool:
+		return &Schema{Type: "boolean"}
+	case float64:
+		if v == math.Trunc(v) {
+			return &Schema{Type: "integer"}
+		}
+		return &Schema{Type: "number"}
+	case int, int64:
+		return &Schema{Type: "integer"}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,210 @@
+package openapipkg
+
+import (
+	"dynamocker/internal/common"
+	urlpatternpkg "dynamocker/internal/url-pattern"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ToMockApis generates a MockApi per path of the document, serving a
+// response per operation. The response is the first successful one (or the
+// default one) of the operation, its body comes from the examples or is
+// synthesized from the schema. The operations which can't be mocked are
+// skipped, the reason is returned
+func ToMockApis(doc *Document) ([]*common.MockApi, []string) {
+	mockApis := make([]*common.MockApi, 0)
+	skipped := make([]string, 0)
+
+	prefix := common.Slug(doc.Info.Title)
+	if prefix == "" {
+		prefix = "openapi"
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		url := strings.Trim(path, "/")
+		if url == "" {
+			skipped = append(skipped, fmt.Sprintf("path '%s' can't be mocked", path))
+			continue
+		}
+		if _, err := urlpatternpkg.Compile(url); err != nil {
+			skipped = append(skipped, fmt.Sprintf("path '%s' can't be mocked: %s", path, err))
+			continue
+		}
+		mockApi := common.MockApi{Name: prefix + "-" + common.Slug(url), URL: url}
+
+		for _, op := range doc.Paths[path].operations() {
+			if op.operation == nil {
+				continue
+			}
+			def, err := doc.responseDef(op.operation)
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s %s: %s", op.method, path, err))
+				continue
+			}
+			if !mockApi.Responses.SetByMethod(op.method, &common.MethodResponse{ResponseDef: *def}) {
+				skipped = append(skipped, fmt.Sprintf("%s %s: method not supported", op.method, path))
+			}
+		}
+		if len(mockApi.Responses.ByMethod()) > 0 {
+			mockApis = append(mockApis, &mockApi)
+		}
+	}
+	return mockApis, skipped
+}
+
+type methodOperation struct {
+	method    string
+	operation *Operation
+}
+
+func (p *PathItem) operations() []methodOperation {
+	return []methodOperation{
+		{http.MethodGet, p.Get},
+		{http.MethodPut, p.Put},
+		{http.MethodPost, p.Post},
+		{http.MethodDelete, p.Delete},
+		{http.MethodOptions, p.Options},
+		{http.MethodHead, p.Head},
+		{http.MethodPatch, p.Patch},
+		{http.MethodTrace, p.Trace},
+	}
+}
+
+// build the mocked response from the first successful response of the operation
+func (doc *Document) responseDef(op *Operation) (*common.ResponseDef, error) {
+	code, response := pickResponse(op.Responses)
+	if response == nil {
+		return nil, fmt.Errorf("no response defined")
+	}
+	response = doc.resolveResponse(response)
+	if response == nil {
+		return nil, fmt.Errorf("response reference not found")
+	}
+	def := common.ResponseDef{Status: code}
+
+	for name, header := range response.Headers {
+		if http.CanonicalHeaderKey(name) == "Content-Type" || header == nil {
+			continue
+		}
+		value := header.Example
+		if value == nil {
+			value = doc.sample(header.Schema, 0)
+		}
+		if value != nil {
+			if def.Headers == nil {
+				def.Headers = make(map[string]string)
+			}
+			def.Headers[name] = fmt.Sprint(value)
+		}
+	}
+
+	mediaType, content := pickContent(response.Content)
+	if content == nil {
+		return &def, nil
+	}
+	value := content.Example
+	if value == nil {
+		value = doc.firstExample(content.Examples)
+	}
+	if value == nil {
+		value = doc.sample(content.Schema, 0)
+	}
+	if mediaType != "application/json" {
+		if def.Headers == nil {
+			def.Headers = make(map[string]string)
+		}
+		def.Headers["Content-Type"] = mediaType
+	}
+	switch {
+	case strings.Contains(mediaType, "json"):
+		def.Body = value
+		def.BodyType = common.BodyTypeJson
+	case value != nil:
+		// only string examples can be served as text: other bodies, such as
+		// the xml ones synthesized from the schema, are left empty
+		if text, ok := value.(string); ok {
+			def.Body = text
+			def.BodyType = common.BodyTypeText
+		}
+	}
+	return &def, nil
+}
+
+// pick the successful response with the lowest status code, otherwise the
+// default response, otherwise the response with the lowest status code
+func pickResponse(responses map[string]*Response) (int, *Response) {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			return statusCode(code), responses[code]
+		}
+	}
+	if response, found := responses["default"]; found {
+		return http.StatusOK, response
+	}
+	for _, code := range codes {
+		if status := statusCode(code); status != 0 {
+			return status, responses[code]
+		}
+	}
+	return 0, nil
+}
+
+// status code of the response, ranges like 2XX are converted to 200
+func statusCode(code string) int {
+	code = strings.NewReplacer("X", "0", "x", "0").Replace(code)
+	status, err := strconv.Atoi(code)
+	if err != nil || status < 100 || status > 599 {
+		return 0
+	}
+	return status
+}
+
+// pick the json media type if present, otherwise the first one
+func pickContent(content map[string]*MediaType) (string, *MediaType) {
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	for _, mediaType := range mediaTypes {
+		if strings.Contains(mediaType, "json") && content[mediaType] != nil {
+			return mediaType, content[mediaType]
+		}
+	}
+	for _, mediaType := range mediaTypes {
+		if content[mediaType] != nil {
+			return mediaType, content[mediaType]
+		}
+	}
+	return "", nil
+}
+
+// value of the first example, sorted by name
+func (doc *Document) firstExample(examples map[string]*Example) interface{} {
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if example := doc.resolveExample(examples[name]); example != nil && example.Value != nil {
+			return example.Value
+		}
+	}
+	return nil
+}
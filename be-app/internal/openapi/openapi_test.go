@@ -0,0 +1,222 @@
+package openapipkg
+
+import (
+	"dynamocker/internal/common"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const petstore = `
+openapi: 3.0.3
+info:
+  title: Pet Store
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      responses:
+        200:
+          description: list of pets
+          headers:
+            X-Total:
+              schema:
+                type: integer
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+    post:
+      responses:
+        '201':
+          $ref: '#/components/responses/Created'
+        '400':
+          description: bad request
+  /pets/{petId}:
+    get:
+      responses:
+        '200':
+          description: a pet
+          content:
+            application/json:
+              examples:
+                second:
+                  value: {"id": 2}
+                first:
+                  $ref: '#/components/examples/Rex'
+    delete:
+      responses:
+        default:
+          description: error
+    put:
+      responses:
+        '204':
+          description: updated
+    trace:
+      responses:
+        '200':
+          description: traced
+  /pets/{petId}/photo:
+    get:
+      responses:
+        2XX:
+          description: photo
+          content:
+            text/csv:
+              example: "id,name\n1,rex\n"
+  /:
+    get:
+      responses:
+        '200':
+          description: root
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id]
+      properties:
+        id:
+          type: integer
+          minimum: 1
+        name:
+          type: string
+        birth:
+          type: string
+          format: date
+        tags:
+          type: array
+          items:
+            type: string
+            enum: [cute, lazy]
+        parent:
+          $ref: '#/components/schemas/Pet'
+  responses:
+    Created:
+      description: created
+      headers:
+        Location:
+          example: /pets/1
+  examples:
+    Rex:
+      value: {"id": 1, "name": "rex"}
+`
+
+func TestParse(t *testing.T) {
+	doc, err := Parse([]byte(petstore))
+	assert.Nil(t, err)
+	assert.Equal(t, "Pet Store", doc.Info.Title)
+	assert.Equal(t, 4, len(doc.Paths))
+	assert.NotNil(t, doc.Paths["/pets"].Get.Responses["200"])
+
+	// json documents are accepted too
+	doc, err = Parse([]byte(`{"openapi":"3.1.0","info":{"title":"t","version":"1"},"paths":{},"components":{"schemas":{"S":{"type":["string","null"]}}}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, SchemaType("string"), doc.Components.Schemas["S"].Type)
+
+	_, err = Parse([]byte(`{"swagger":"2.0"}`))
+	assert.EqualError(t, err, "unsupported OpenAPI version '', 3.x expected")
+	_, err = Parse([]byte("paths: [unclosed"))
+	assert.NotNil(t, err)
+}
+
+func TestToMockApis(t *testing.T) {
+	doc, err := Parse([]byte(petstore))
+	assert.Nil(t, err)
+	mockApis, skipped := ToMockApis(doc)
+	assert.Equal(t, 3, len(mockApis))
+	assert.Equal(t, []string{
+		"path '/' can't be mocked",
+		"TRACE /pets/{petId}: method not supported",
+	}, skipped)
+
+	pets := mockApis[0]
+	assert.Equal(t, "pet-store-pets", pets.Name)
+	assert.Equal(t, "pets", pets.URL)
+	// body synthesized from the schema, the recursion is stopped
+	list := pets.Responses.Get.Body.([]interface{})
+	pet := list[0].(map[string]interface{})
+	assert.Equal(t, 1, pet["id"])
+	assert.Equal(t, "2024-01-01", pet["birth"])
+	assert.Equal(t, []interface{}{"cute"}, pet["tags"])
+	assert.NotNil(t, pet["parent"])
+	assert.Equal(t, map[string]string{"X-Total": "0"}, pets.Responses.Get.Headers)
+	// referenced response
+	assert.Equal(t, http.StatusCreated, pets.Responses.Post.Status)
+	assert.Equal(t, map[string]string{"Location": "/pets/1"}, pets.Responses.Post.Headers)
+
+	pet1 := mockApis[1]
+	assert.Equal(t, "pets/{petId}", pet1.URL)
+	assert.Equal(t, http.StatusNoContent, pet1.Responses.Put.Status)
+	// first example by name, resolving the reference
+	assert.Equal(t, map[string]interface{}{"id": float64(1), "name": "rex"}, pet1.Responses.Get.Body)
+	assert.Equal(t, http.StatusOK, pet1.Responses.Delete.Status)
+
+	photo := mockApis[2]
+	assert.Equal(t, http.StatusOK, photo.Responses.Get.Status)
+	assert.Equal(t, common.BodyTypeText, photo.Responses.Get.BodyType)
+	assert.Equal(t, "id,name\n1,rex\n", photo.Responses.Get.Body)
+	assert.Equal(t, "text/csv", photo.Responses.Get.ContentType())
+}
+
+func TestFromMockApis(t *testing.T) {
+	var users common.MockApi
+	err := json.Unmarshal([]byte(`{
+		"name": "users",
+		"url": "users/{id:[0-9]+}/*",
+		"responses": {
+			"get": {
+				"body": {"id": 1, "name": "rex", "score": 1.5, "tags": ["a"], "active": true},
+				"candidates": [
+					{"match": {"query": {"missing": {}}}, "status": 404, "headers": {"X-Reason": "missing"}, "body": "not found", "bodyType": "text"}
+				]
+			},
+			"delete": {"status": 204}
+		}
+	}`), &users)
+	if err != nil {
+		t.Fatalf("error while unmarshaling: %s", err)
+	}
+	logo := common.MockApi{Name: "logo", URL: "logo.png"}
+	logo.Responses.Get = &common.MethodResponse{ResponseDef: common.ResponseDef{Body: "iVBORw==", BodyType: common.BodyTypeBase64, Headers: map[string]string{"Content-Type": "image/png"}}}
+
+	doc := FromMockApis([]*common.MockApi{&users, &logo})
+	assert.Equal(t, 2, len(doc.Paths))
+
+	item := doc.Paths["/users/{id}/{wildcard1}"]
+	assert.Equal(t, []*Parameter{
+		{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string", Pattern: "^(?:[0-9]+)$"}},
+		{Name: "wildcard1", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+	}, item.Parameters)
+	assert.Equal(t, "users-get", item.Get.OperationId)
+	ok := item.Get.Responses["200"]
+	assert.Equal(t, "OK", ok.Description)
+	schema := ok.Content["application/json"].Schema
+	assert.Equal(t, SchemaType("object"), schema.Type)
+	assert.Equal(t, SchemaType("integer"), schema.Properties["id"].Type)
+	assert.Equal(t, SchemaType("number"), schema.Properties["score"].Type)
+	assert.Equal(t, SchemaType("string"), schema.Properties["tags"].Items.Type)
+	assert.Equal(t, SchemaType("boolean"), schema.Properties["active"].Type)
+	notFound := item.Get.Responses["404"]
+	assert.Equal(t, "not found", notFound.Content["text/plain"].Example)
+	assert.Equal(t, "missing", notFound.Headers["X-Reason"].Example)
+	assert.Nil(t, item.Delete.Responses["204"].Content)
+	assert.Nil(t, item.Post)
+
+	png := doc.Paths["/logo.png"].Get.Responses["200"].Content["image/png"]
+	assert.Equal(t, &Schema{Type: "string", Format: "binary"}, png.Schema)
+
+	// the exported document can be imported again
+	data, err := doc.Yaml()
+	assert.Nil(t, err)
+	imported, err := Parse(data)
+	assert.Nil(t, err)
+	mockApis, _ := ToMockApis(imported)
+	assert.Equal(t, 2, len(mockApis))
+	assert.Equal(t, "users/{id}/{wildcard1}", mockApis[1].URL)
+	assert.Equal(t, http.StatusNoContent, mockApis[1].Responses.Delete.Status)
+	assert.Equal(t, "rex", mockApis[1].Responses.Get.Body.(map[string]interface{})["name"])
+}
@@ -0,0 +1,137 @@
+package openapipkg
+
+import "strings"
+
+// maximum depth of the synthesized bodies, it stops recursive schemas
+const maxSampleDepth = 8
+
+// sample synthesizes a value valid for the schema. Examples, defaults and
+// enums are preferred, otherwise a value is generated from the type
+func (doc *Document) sample(schema *Schema, depth int) interface{} {
+	if depth > maxSampleDepth {
+		return nil
+	}
+	schema = doc.resolveSchema(schema)
+	if schema == nil {
+		return nil
+	}
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.AllOf) > 0:
+		merged := make(map[string]interface{})
+		for _, sub := range schema.AllOf {
+			if object, ok := doc.sample(sub, depth+1).(map[string]interface{}); ok {
+				for key, value := range object {
+					merged[key] = value
+				}
+			}
+		}
+		return merged
+	case len(schema.OneOf) > 0:
+		return doc.sample(schema.OneOf[0], depth+1)
+	case len(schema.AnyOf) > 0:
+		return doc.sample(schema.AnyOf[0], depth+1)
+	}
+
+	switch schema.Type {
+	case "object":
+		object := make(map[string]interface{})
+		for name, property := range schema.Properties {
+			if value := doc.sample(property, depth+1); value != nil {
+				object[name] = value
+			}
+		}
+		return object
+	case "array":
+		item := doc.sample(schema.Items, depth+1)
+		if item == nil {
+			return []interface{}{}
+		}
+		return []interface{}{item}
+	case "string":
+		return sampleString(schema.Format)
+	case "integer":
+		if schema.Minimum != nil {
+			return int(*schema.Minimum)
+		}
+		return 0
+	case "number":
+		if schema.Minimum != nil {
+			return *schema.Minimum
+		}
+		return 0.0
+	case "boolean":
+		return true
+	case "":
+		// untyped schemas with properties are objects
+		if len(schema.Properties) > 0 {
+			return doc.sample(&Schema{Type: "object", Properties: schema.Properties}, depth)
+		}
+	}
+	return nil
+}
+
+func sampleString(format string) string {
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	case "ipv4":
+		return "127.0.0.1"
+	case "byte":
+		return "c3RyaW5n"
+	default:
+		return "string"
+	}
+}
+
+// references to the components of the document, e.g. #/components/schemas/User
+func (doc *Document) component(ref string, kind string) (string, bool) {
+	name, found := strings.CutPrefix(ref, "#/components/"+kind+"/")
+	return name, found && doc.Components != nil
+}
+
+func (doc *Document) resolveSchema(schema *Schema) *Schema {
+	for depth := 0; schema != nil && schema.Ref != "" && depth < maxSampleDepth; depth++ {
+		name, found := doc.component(schema.Ref, "schemas")
+		if !found {
+			return nil
+		}
+		schema = doc.Components.Schemas[name]
+	}
+	return schema
+}
+
+func (doc *Document) resolveResponse(response *Response) *Response {
+	for depth := 0; response != nil && response.Ref != "" && depth < maxSampleDepth; depth++ {
+		name, found := doc.component(response.Ref, "responses")
+		if !found {
+			return nil
+		}
+		response = doc.Components.Responses[name]
+	}
+	return response
+}
+
+func (doc *Document) resolveExample(example *Example) *Example {
+	for depth := 0; example != nil && example.Ref != "" && depth < maxSampleDepth; depth++ {
+		name, found := doc.component(example.Ref, "examples")
+		if !found {
+			return nil
+		}
+		example = doc.Components.Examples[name]
+	}
+	return example
+}
@@ -0,0 +1,180 @@
+package postmanpkg
+
+import (
+	"dynamocker/internal/common"
+	urlpatternpkg "dynamocker/internal/url-pattern"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// FromMockApis describes the mockApis as a Postman collection, with a request
+// per method of each mockApi. The responses served by the method (default
+// response, sequences and candidates) are saved as examples. The requests
+// use the baseUrl variable, set to the given url.
+func FromMockApis(mockApis []*common.MockApi, baseUrl string) *Collection {
+	collection := Collection{
+		Info: Info{
+			Name:        "dynamocker",
+			Description: "mock APIs served by dynamocker",
+			Schema:      SchemaV21,
+		},
+		Item:     make([]Item, 0),
+		Variable: []KeyValue{{Key: "baseUrl", Value: baseUrl}},
+	}
+
+	sorted := append(make([]*common.MockApi, 0, len(mockApis)), mockApis...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	for _, mockApi := range sorted {
+		pattern, err := urlpatternpkg.Compile(mockApi.URL)
+		if err != nil {
+			continue
+		}
+		url := exportUrl(pattern)
+
+		responses := mockApi.Responses.ByMethod()
+		methods := make([]string, 0, len(responses))
+		for method := range responses {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			request := Request{Method: method, Header: make([]KeyValue, 0), URL: url}
+			collection.Item = append(collection.Item, Item{
+				Name:     mockApi.Name + " " + method,
+				Request:  &request,
+				Response: exportExamples(responses[method], request),
+			})
+		}
+	}
+	return &collection
+}
+
+// url of the request: url parameters become path variables ({id} -> :id)
+func exportUrl(pattern *urlpatternpkg.Pattern) URL {
+	template, params := pattern.Template()
+	path := make([]string, 0)
+	for _, segment := range strings.Split(template, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") {
+			segment = ":" + strings.Trim(segment, "{}")
+		}
+		path = append(path, segment)
+	}
+	url := URL{
+		Raw:  "{{baseUrl}}/" + strings.Join(path, "/"),
+		Host: []string{"{{baseUrl}}"},
+		Path: path,
+	}
+	for _, param := range params {
+		url.Variable = append(url.Variable, KeyValue{Key: param.Name, Value: ""})
+	}
+	return url
+}
+
+func exportExamples(response *common.MethodResponse, request Request) []Response {
+	examples := make([]Response, 0)
+	add := func(name string, def *common.ResponseDef, query map[string]string) {
+		original := request
+		if len(query) > 0 {
+			original.URL.Query = make([]KeyValue, 0, len(query))
+			keys := make([]string, 0, len(query))
+			for key := range query {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				original.URL.Query = append(original.URL.Query, KeyValue{Key: key, Value: query[key]})
+			}
+			original.URL.Raw += "?" + encodeQuery(original.URL.Query)
+		}
+		examples = append(examples, exportExample(name, def, &original))
+	}
+
+	if !response.ResponseDef.IsEmpty() {
+		add("default", &response.ResponseDef, nil)
+	}
+	for i := range response.Sequence {
+		add(fmt.Sprintf("sequence %d", i+1), &response.Sequence[i], nil)
+	}
+	for i, candidate := range response.Candidates {
+		query := candidateQuery(candidate.Match)
+		if !candidate.ResponseDef.IsEmpty() {
+			add(fmt.Sprintf("candidate %d", i+1), &candidate.ResponseDef, query)
+		}
+		for j := range candidate.Sequence {
+			add(fmt.Sprintf("candidate %d sequence %d", i+1, j+1), &candidate.Sequence[j], query)
+		}
+	}
+	return examples
+}
+
+// query parameters of the original request, from the equalTo conditions of the candidate
+func candidateQuery(matcher common.RequestMatcher) map[string]string {
+	query := make(map[string]string)
+	for key, condition := range matcher.Query {
+		if condition.EqualTo != nil {
+			query[key] = fmt.Sprint(condition.EqualTo)
+		}
+	}
+	return query
+}
+
+func encodeQuery(query []KeyValue) string {
+	params := make([]string, 0, len(query))
+	for _, param := range query {
+		params = append(params, param.Key+"="+param.Value)
+	}
+	return strings.Join(params, "&")
+}
+
+// binary bodies are not exported: Postman examples hold text only
+func exportExample(name string, def *common.ResponseDef, original *Request) Response {
+	example := Response{
+		Name:            name,
+		OriginalRequest: original,
+		Status:          http.StatusText(def.StatusCode()),
+		Code:            def.StatusCode(),
+		Header:          make([]KeyValue, 0),
+	}
+	keys := make([]string, 0, len(def.Headers))
+	for key := range def.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		example.Header = append(example.Header, KeyValue{Key: key, Value: def.Headers[key]})
+	}
+	if def.Body == nil || def.BodyType == common.BodyTypeBase64 {
+		return example
+	}
+	if !hasContentType(def.Headers) {
+		example.Header = append(example.Header, KeyValue{Key: "Content-Type", Value: def.ContentType()})
+	}
+	switch def.BodyType {
+	case common.BodyTypeText:
+		example.Body = fmt.Sprint(def.Body)
+		example.PreviewLanguage = "text"
+	default:
+		body, err := json.MarshalIndent(def.Body, "", "    ")
+		if err == nil {
+			example.Body = string(body)
+		}
+		example.PreviewLanguage = "json"
+	}
+	return example
+}
+
+func hasContentType(headers map[string]string) bool {
+	for key := range headers {
+		if http.CanonicalHeaderKey(key) == "Content-Type" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package postmanpkg
+
+import (
+	"dynamocker/internal/common"
+	urlpatternpkg "dynamocker/internal/url-pattern"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ToMockApis generates a MockApi per url of the collection, serving the
+// saved example responses of each request. Path variables (:id) become url
+// parameters ({id}). The first example is the default response, the other
+// ones having query parameters in their original request become candidates
+// matching those parameters. The requests without examples are skipped
+func ToMockApis(collection *Collection) ([]*common.MockApi, []string) {
+	mockApis := make(common.MockApisByUrl)
+	skipped := make([]string, 0)
+
+	prefix := common.Slug(collection.Info.Name)
+	if prefix == "" {
+		prefix = "postman"
+	}
+
+	for _, item := range flatten(collection.Item, "") {
+		request := item.Request
+		method := strings.ToUpper(request.Method)
+		if method == "" {
+			method = http.MethodGet
+		}
+		path, err := mockUrl(&request.URL)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s (%s %s): %s", item.Name, method, request.URL.Raw, err))
+			continue
+		}
+		if len(item.Response) == 0 {
+			skipped = append(skipped, fmt.Sprintf("%s (%s %s): no example response saved", item.Name, method, request.URL.Raw))
+			continue
+		}
+
+		if !mockApis.SetResponse(path, prefix+"-"+common.Slug(path), method, methodResponse(item.Response)) {
+			skipped = append(skipped, fmt.Sprintf("%s (%s %s): method not supported", item.Name, method, request.URL.Raw))
+		}
+	}
+	return mockApis.Sorted(), skipped
+}
+
+// requests of the items and of the nested folders, named after their folders
+func flatten(items []Item, folder string) []Item {
+	res := make([]Item, 0)
+	for _, item := range items {
+		name := item.Name
+		if folder != "" {
+			name = folder + "/" + item.Name
+		}
+		if item.Request != nil {
+			item.Name = name
+			res = append(res, item)
+		}
+		res = append(res, flatten(item.Item, name)...)
+	}
+	return res
+}
+
+func methodResponse(examples []Response) *common.MethodResponse {
+	var response common.MethodResponse
+	defaultSet := false
+	for i := range examples {
+		def := responseDef(&examples[i])
+		query := exampleQuery(&examples[i])
+		if len(query) == 0 && !defaultSet {
+			response.ResponseDef = def
+			defaultSet = true
+			continue
+		}
+		if len(query) == 0 {
+			continue
+		}
+		matcher := common.RequestMatcher{Query: make(map[string]common.ValueMatcher)}
+		for key, value := range query {
+			matcher.Query[key] = common.ValueMatcher{EqualTo: value}
+		}
+		response.Candidates = append(response.Candidates, common.Candidate{Match: matcher, ResponseDef: def})
+	}
+	// if all the examples have a query, the first one is the default response too
+	if !defaultSet {
+		response.ResponseDef = responseDef(&examples[0])
+	}
+	return &response
+}
+
+func exampleQuery(example *Response) map[string]string {
+	query := make(map[string]string)
+	if example.OriginalRequest == nil {
+		return query
+	}
+	for _, param := range example.OriginalRequest.URL.Query {
+		if !param.Disabled && param.Key != "" {
+			query[param.Key] = param.Value
+		}
+	}
+	return query
+}
+
+func responseDef(example *Response) common.ResponseDef {
+	status := example.Code
+	if status == 0 {
+		status = http.StatusOK
+	}
+	headers := http.Header{}
+	for _, header := range example.Header {
+		if !header.Disabled {
+			headers.Add(header.Key, header.Value)
+		}
+	}
+	// the body is saved decoded in the example
+	common.DelTransportHeaders(headers)
+	if headers.Get("Content-Type") == "" && example.PreviewLanguage == "json" {
+		headers.Set("Content-Type", "application/json")
+	}
+	return common.NewResponseDef(status, headers, []byte(example.Body))
+}
+
+// url of the mockApi: the path of the request. Path variables (:id) and
+// Postman variables ({{id}}) become url parameters
+func mockUrl(url *URL) (string, error) {
+	segments := url.Path
+	if len(segments) == 0 {
+		segments = rawPath(url.Raw)
+	}
+	parts := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		switch {
+		case segment == "":
+			continue
+		case strings.HasPrefix(segment, ":") && len(segment) > 1:
+			parts = append(parts, "{"+segment[1:]+"}")
+		case strings.HasPrefix(segment, "{{") && strings.HasSuffix(segment, "}}") && len(segment) > 4:
+			parts = append(parts, "{"+segment[2:len(segment)-2]+"}")
+		default:
+			parts = append(parts, segment)
+		}
+	}
+	path := strings.Join(parts, "/")
+	if path == "" {
+		return "", fmt.Errorf("the root url can't be mocked")
+	}
+	if _, err := urlpatternpkg.Compile(path); err != nil {
+		return "", fmt.Errorf("the url can't be mocked: %s", err)
+	}
+	return path, nil
+}
+
+// segments of the path of a raw url, e.g. {{baseUrl}}/users/:id?page=1
+func rawPath(raw string) []string {
+	raw, _, _ = strings.Cut(raw, "#")
+	raw, _, _ = strings.Cut(raw, "?")
+	if _, rest, found := strings.Cut(raw, "://"); found {
+		raw = rest
+	}
+	segments := strings.Split(raw, "/")
+	// the first segment is the host, or the variable holding the base url
+	return segments[1:]
+}
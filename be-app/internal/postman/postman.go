@@ -0,0 +1,93 @@
+package postmanpkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// url of the schema of the collections, version 2.1
+const SchemaV21 = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// Collection is the subset of a Postman collection (v2.1) used to import and
+// export the mockApis
+type Collection struct {
+	Info     Info       `json:"info"`
+	Item     []Item     `json:"item"`
+	Variable []KeyValue `json:"variable,omitempty"`
+}
+
+type Info struct {
+	PostmanId   string `json:"_postman_id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// Item is either a request, with its saved example responses, or a folder of items
+type Item struct {
+	Name     string     `json:"name"`
+	Item     []Item     `json:"item,omitempty"`
+	Request  *Request   `json:"request,omitempty"`
+	Response []Response `json:"response,omitempty"`
+}
+
+type Request struct {
+	Method string     `json:"method"`
+	Header []KeyValue `json:"header"`
+	URL    URL        `json:"url"`
+}
+
+// URL of a request. Postman accepts either a string or an object
+type URL struct {
+	Raw      string     `json:"raw"`
+	Host     []string   `json:"host,omitempty"`
+	Path     []string   `json:"path,omitempty"`
+	Query    []KeyValue `json:"query,omitempty"`
+	Variable []KeyValue `json:"variable,omitempty"`
+}
+
+type KeyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// Response is an example response saved for a request
+type Response struct {
+	Name            string     `json:"name"`
+	OriginalRequest *Request   `json:"originalRequest,omitempty"`
+	Status          string     `json:"status,omitempty"`
+	Code            int        `json:"code"`
+	PreviewLanguage string     `json:"_postman_previewlanguage,omitempty"`
+	Header          []KeyValue `json:"header"`
+	Body            string     `json:"body"`
+}
+
+func (u *URL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = URL{Raw: raw}
+		return nil
+	}
+	// the alias type has no UnmarshalJSON, avoiding the recursion
+	type urlAlias URL
+	var url urlAlias
+	if err := json.Unmarshal(data, &url); err != nil {
+		return err
+	}
+	*u = URL(url)
+	return nil
+}
+
+// Parse reads a Postman collection
+func Parse(data []byte) (*Collection, error) {
+	var collection Collection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("invalid collection: %s", err)
+	}
+	if !strings.Contains(collection.Info.Schema, "v2.1") {
+		return nil, fmt.Errorf("unsupported collection schema '%s', v2.1 expected", collection.Info.Schema)
+	}
+	return &collection, nil
+}
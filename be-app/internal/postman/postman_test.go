@@ -0,0 +1,149 @@
+package postmanpkg
+
+import (
+	"dynamocker/internal/common"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const collection = `{
+  "info": {"name": "Shop API", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "item": [
+    {
+      "name": "Orders",
+      "item": [
+        {
+          "name": "List orders",
+          "request": {"method": "GET", "header": [], "url": {"raw": "{{baseUrl}}/orders?status=open", "host": ["{{baseUrl}}"], "path": ["orders"]}},
+          "response": [
+            {
+              "name": "archived",
+              "originalRequest": {"method": "GET", "header": [], "url": {"raw": "{{baseUrl}}/orders?status=archived", "query": [{"key": "status", "value": "archived"}]}},
+              "code": 200,
+              "_postman_previewlanguage": "json",
+              "header": [],
+              "body": "[]"
+            },
+            {
+              "name": "all",
+              "code": 200,
+              "header": [{"key": "Content-Type", "value": "application/json"}, {"key": "X-Total", "value": "1"}, {"key": "X-Disabled", "value": "1", "disabled": true}],
+              "body": "[{\"id\": 1}]"
+            }
+          ]
+        },
+        {
+          "name": "Get order",
+          "request": {"method": "GET", "header": [], "url": "https://shop.example.com/orders/:orderId"},
+          "response": [
+            {"name": "csv", "code": 200, "header": [{"key": "Content-Type", "value": "text/csv"}], "body": "id\n1\n"}
+          ]
+        }
+      ]
+    },
+    {
+      "name": "Delete order",
+      "request": {"method": "DELETE", "header": [], "url": {"raw": "{{baseUrl}}/orders/{{orderId}}", "host": ["{{baseUrl}}"], "path": ["orders", "{{orderId}}"]}},
+      "response": [{"name": "deleted", "code": 204, "header": [], "body": ""}]
+    },
+    {
+      "name": "No examples",
+      "request": {"method": "POST", "header": [], "url": "{{baseUrl}}/orders"}
+    }
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	c, err := Parse([]byte(collection))
+	assert.Nil(t, err)
+	assert.Equal(t, "Shop API", c.Info.Name)
+	assert.Equal(t, "https://shop.example.com/orders/:orderId", c.Item[0].Item[1].Request.URL.Raw)
+
+	_, err = Parse([]byte(`{"info": {"schema": "https://schema.getpostman.com/json/collection/v2.0.0/collection.json"}}`))
+	assert.NotNil(t, err)
+	_, err = Parse([]byte(`[]`))
+	assert.NotNil(t, err)
+}
+
+func TestToMockApis(t *testing.T) {
+	c, err := Parse([]byte(collection))
+	assert.Nil(t, err)
+	mockApis, skipped := ToMockApis(c)
+	assert.Equal(t, []string{"No examples (POST {{baseUrl}}/orders): no example response saved"}, skipped)
+	assert.Equal(t, 2, len(mockApis))
+
+	orders := mockApis[0]
+	assert.Equal(t, "shop-api-orders", orders.Name)
+	assert.Equal(t, "orders", orders.URL)
+	// the example without query is the default one
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": float64(1)}}, orders.Responses.Get.Body)
+	assert.Equal(t, map[string]string{"X-Total": "1"}, orders.Responses.Get.Headers)
+	assert.Equal(t, 1, len(orders.Responses.Get.Candidates))
+	candidate := orders.Responses.Get.Candidates[0]
+	assert.Equal(t, map[string]common.ValueMatcher{"status": {EqualTo: "archived"}}, candidate.Match.Query)
+	assert.Equal(t, []interface{}{}, candidate.Body)
+
+	order := mockApis[1]
+	assert.Equal(t, "orders/{orderId}", order.URL)
+	assert.Equal(t, "id\n1\n", order.Responses.Get.Body)
+	assert.Equal(t, "text/csv", order.Responses.Get.ContentType())
+	assert.Equal(t, http.StatusNoContent, order.Responses.Delete.Status)
+}
+
+func TestFromMockApis(t *testing.T) {
+	var users common.MockApi
+	err := json.Unmarshal([]byte(`{
+		"name": "users",
+		"url": "users/{id:[0-9]+}",
+		"responses": {
+			"get": {
+				"body": {"id": 1},
+				"candidates": [
+					{"match": {"query": {"expand": {"equalTo": true}}}, "sequence": [{"status": 202}, {"body": "done", "bodyType": "text"}]}
+				]
+			},
+			"post": {"status": 201, "headers": {"Location": "/users/1"}},
+			"delete": {"body": "AAE=", "bodyType": "base64"}
+		}
+	}`), &users)
+	if err != nil {
+		t.Fatalf("error while unmarshaling: %s", err)
+	}
+
+	c := FromMockApis([]*common.MockApi{&users}, "http://localhost:8150/dynamocker/api/serve-mock-api")
+	assert.Equal(t, SchemaV21, c.Info.Schema)
+	assert.Equal(t, []KeyValue{{Key: "baseUrl", Value: "http://localhost:8150/dynamocker/api/serve-mock-api"}}, c.Variable)
+	assert.Equal(t, 3, len(c.Item))
+
+	del, get, post := c.Item[0], c.Item[1], c.Item[2]
+	assert.Equal(t, "users GET", get.Name)
+	assert.Equal(t, "{{baseUrl}}/users/:id", get.Request.URL.Raw)
+	assert.Equal(t, []string{"users", ":id"}, get.Request.URL.Path)
+	assert.Equal(t, 3, len(get.Response))
+	assert.Equal(t, "{\n    \"id\": 1\n}", get.Response[0].Body)
+	assert.Equal(t, []KeyValue{{Key: "Content-Type", Value: "application/json"}}, get.Response[0].Header)
+	assert.Equal(t, "candidate 1 sequence 1", get.Response[1].Name)
+	assert.Equal(t, 202, get.Response[1].Code)
+	assert.Equal(t, "{{baseUrl}}/users/:id?expand=true", get.Response[1].OriginalRequest.URL.Raw)
+	assert.Equal(t, "done", get.Response[2].Body)
+	assert.Equal(t, "Created", post.Response[0].Status)
+	assert.Equal(t, []KeyValue{{Key: "Location", Value: "/users/1"}}, post.Response[0].Header)
+	// binary bodies are not exported
+	assert.Equal(t, "", del.Response[0].Body)
+
+	// the exported collection can be imported again
+	data, err := json.Marshal(c)
+	assert.Nil(t, err)
+	imported, err := Parse(data)
+	assert.Nil(t, err)
+	mockApis, skipped := ToMockApis(imported)
+	assert.Empty(t, skipped)
+	assert.Equal(t, 1, len(mockApis))
+	assert.Equal(t, "users/{id}", mockApis[0].URL)
+	assert.Equal(t, map[string]interface{}{"id": float64(1)}, mockApis[0].Responses.Get.Body)
+	assert.Equal(t, map[string]common.ValueMatcher{"expand": {EqualTo: "true"}}, mockApis[0].Responses.Get.Candidates[0].Match.Query)
+	assert.Equal(t, http.StatusCreated, mockApis[0].Responses.Post.Status)
+}
@@ -0,0 +1,140 @@
+package proxypkg
+
+import (
+	"dynamocker/internal/config"
+	urlpatternpkg "dynamocker/internal/url-pattern"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Fallback passes through the requests matching no mockApi. A request is
+// proxied if its path matches one of the include patterns (or no include
+// pattern is set) and none of the exclude patterns.
+type Fallback struct {
+	Upstream string
+	Include  []*urlpatternpkg.Pattern
+	Exclude  []*urlpatternpkg.Pattern
+	// headers set on the proxied requests, overriding the original ones
+	SetHeaders map[string]string
+	// headers removed from the proxied requests
+	RemoveHeaders []string
+}
+
+// pass-through proxy in use, nil if disabled
+var fallback *Fallback
+
+// Init sets up the pass-through proxy according to the configuration
+func Init() error {
+	fallback = nil
+	if config.GetProxyUpstream() == "" {
+		return nil
+	}
+	f, err := NewFallback(
+		config.GetProxyUpstream(),
+		config.GetProxyInclude(),
+		config.GetProxyExclude(),
+		config.GetProxySetHeaders(),
+		config.GetProxyRemoveHeaders(),
+	)
+	if err != nil {
+		return err
+	}
+	fallback = f
+	log.Infof("requests matching no mockApi are passed through to %s", f.Upstream)
+	return nil
+}
+
+// GetFallback returns the pass-through proxy, nil if disabled
+func GetFallback() *Fallback {
+	return fallback
+}
+
+// NewFallback parses the comma separated include and exclude url patterns,
+// the 'Name:Value' headers to be set and the names of the headers to be removed
+func NewFallback(upstream string, include string, exclude string, setHeaders string, removeHeaders string) (*Fallback, error) {
+	if parsed, err := url.Parse(upstream); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid proxy upstream '%s'", upstream)
+	}
+	f := Fallback{Upstream: upstream, SetHeaders: make(map[string]string)}
+
+	var err error
+	if f.Include, err = compilePatterns(include); err != nil {
+		return nil, fmt.Errorf("invalid proxy include pattern: %s", err)
+	}
+	if f.Exclude, err = compilePatterns(exclude); err != nil {
+		return nil, fmt.Errorf("invalid proxy exclude pattern: %s", err)
+	}
+	for _, header := range splitList(setHeaders) {
+		name, value, found := strings.Cut(header, ":")
+		if !found || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid proxy header '%s', 'Name:Value' expected", header)
+		}
+		f.SetHeaders[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	f.RemoveHeaders = splitList(removeHeaders)
+	return &f, nil
+}
+
+// Proxies returns true if the path has to be passed through
+func (f *Fallback) Proxies(path string) bool {
+	if f == nil {
+		return false
+	}
+	included := len(f.Include) == 0
+	for _, pattern := range f.Include {
+		if _, match := pattern.Match(path); match {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, pattern := range f.Exclude {
+		if _, match := pattern.Match(path); match {
+			return false
+		}
+	}
+	return true
+}
+
+// Rewrite removes and sets the configured headers of the proxied request
+func (f *Fallback) Rewrite(req *http.Request) {
+	for _, name := range f.RemoveHeaders {
+		req.Header.Del(name)
+	}
+	for name, value := range f.SetHeaders {
+		if http.CanonicalHeaderKey(name) == "Host" {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+}
+
+func compilePatterns(list string) ([]*urlpatternpkg.Pattern, error) {
+	patterns := make([]*urlpatternpkg.Pattern, 0)
+	for _, url := range splitList(list) {
+		pattern, err := urlpatternpkg.Compile(url)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// split the comma separated list, dropping the empty items
+func splitList(list string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
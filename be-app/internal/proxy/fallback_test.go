@@ -0,0 +1,76 @@
+package proxypkg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInit(t *testing.T) {
+	assert.Nil(t, Init())
+	assert.Nil(t, GetFallback())
+	assert.False(t, GetFallback().Proxies("users"))
+
+	if err := os.Setenv("DYNA_PROXY_UPSTREAM", "not an url"); err != nil {
+		t.Fatalf("cannot set env variable: %s", err)
+	}
+	defer os.Unsetenv("DYNA_PROXY_UPSTREAM")
+	assert.EqualError(t, Init(), "invalid proxy upstream 'not an url'")
+
+	os.Setenv("DYNA_PROXY_UPSTREAM", "http://localhost:9000")
+	os.Setenv("DYNA_PROXY_INCLUDE", "users/**, orders")
+	defer os.Unsetenv("DYNA_PROXY_INCLUDE")
+	assert.Nil(t, Init())
+	assert.Equal(t, "http://localhost:9000", GetFallback().Upstream)
+	assert.Equal(t, 2, len(GetFallback().Include))
+}
+
+func TestNewFallback(t *testing.T) {
+	_, err := NewFallback("http://upstream", "users/**/x", "", "", "")
+	assert.NotNil(t, err)
+	_, err = NewFallback("http://upstream", "", "", "Authorization", "")
+	assert.EqualError(t, err, "invalid proxy header 'Authorization', 'Name:Value' expected")
+
+	f, err := NewFallback("http://upstream", "", "", "Authorization: Bearer abc, Host:api.example.com", "Cookie,X-Debug")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer abc", "Host": "api.example.com"}, f.SetHeaders)
+	assert.Equal(t, []string{"Cookie", "X-Debug"}, f.RemoveHeaders)
+}
+
+func TestProxies(t *testing.T) {
+	f, err := NewFallback("http://upstream", "", "admin/**", "", "")
+	assert.Nil(t, err)
+	assert.True(t, f.Proxies("users/1"))
+	assert.False(t, f.Proxies("admin/users"))
+
+	f, err = NewFallback("http://upstream", "users/{id}, orders/**", "orders/secret", "", "")
+	assert.Nil(t, err)
+	assert.True(t, f.Proxies("users/1"))
+	assert.True(t, f.Proxies("orders/1/items"))
+	assert.False(t, f.Proxies("orders/secret"))
+	assert.False(t, f.Proxies("products"))
+}
+
+func TestRewrite(t *testing.T) {
+	f, err := NewFallback("http://upstream", "", "", "Authorization:Bearer abc,Host:api.example.com", "Cookie")
+	assert.Nil(t, err)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer abc", r.Header.Get("Authorization"))
+		assert.Equal(t, "api.example.com", r.Host)
+		assert.Empty(t, r.Header.Get("Cookie"))
+		assert.Equal(t, "abc", r.Header.Get("X-Request-Id"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("Authorization", "Basic xyz")
+	req.Header.Set("Cookie", "session=1")
+	req.Header.Set("X-Request-Id", "abc")
+	res, err := Forward(req, upstream.URL, "users", f.Rewrite)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, res.Status)
+}
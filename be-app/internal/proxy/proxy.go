@@ -0,0 +1,119 @@
+package proxypkg
+
+import (
+	"bytes"
+	"dynamocker/internal/common"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// headers meaningful only for a single connection, never forwarded
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// redirects are returned to the caller, as a real upstream would do
+var client = &http.Client{
+	Timeout: 30 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Response returned by the upstream, with the body already read
+type Response struct {
+	Status  int
+	Headers http.Header
+	Body    []byte
+}
+
+// Forward sends the request to the upstream base url, appending the path and
+// the query of the original request. The body of the request is restored
+// after being forwarded. If not nil, rewrite modifies the forwarded request.
+func Forward(r *http.Request, upstream string, path string, rewrite func(*http.Request)) (*Response, error) {
+	target := strings.TrimSuffix(upstream, "/") + "/" + strings.TrimPrefix(path, "/")
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, fmt.Errorf("error while reading request body: %s", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream url '%s': %s", target, err)
+	}
+	req.Header = r.Header.Clone()
+	removeHopByHopHeaders(req.Header)
+	if rewrite != nil {
+		rewrite(req)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error while forwarding the request to the upstream: %s", err)
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading the upstream response: %s", err)
+	}
+	removeHopByHopHeaders(res.Header)
+	return &Response{Status: res.StatusCode, Headers: res.Header, Body: resBody}, nil
+}
+
+// Write copies the upstream response to the client
+func (res *Response) Write(w http.ResponseWriter) {
+	for key, values := range res.Headers {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(res.Status)
+	w.Write(res.Body)
+}
+
+// ResponseDef converts the upstream response into a mocked response, see
+// common.NewResponseDef
+func (res *Response) ResponseDef() common.ResponseDef {
+	return common.NewResponseDef(res.Status, res.Headers, res.Body)
+}
+
+// RecordedMockApi returns a new MockApi serving the upstream response for
+// the given method and path
+func RecordedMockApi(method string, path string, res *Response) (*common.MockApi, error) {
+	mockApi := common.MockApi{
+		Name: "recorded-" + common.Slug(method+"-"+path),
+		URL:  strings.Trim(path, "/"),
+	}
+	def := res.ResponseDef()
+	if !mockApi.Responses.SetByMethod(method, &common.MethodResponse{ResponseDef: def}) {
+		return nil, fmt.Errorf("method %s cannot be recorded", method)
+	}
+	return &mockApi, nil
+}
+
+func removeHopByHopHeaders(header http.Header) {
+	for _, connectionHeader := range header.Values("Connection") {
+		for _, name := range strings.Split(connectionHeader, ",") {
+			header.Del(strings.TrimSpace(name))
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		header.Del(name)
+	}
+}
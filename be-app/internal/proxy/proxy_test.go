@@ -0,0 +1,97 @@
+package proxypkg
+
+import (
+	"dynamocker/internal/common"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForward(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "PATCH", r.Method)
+		assert.Equal(t, "/base/users/1", r.URL.Path)
+		assert.Equal(t, "a=b", r.URL.RawQuery)
+		assert.Equal(t, "abc", r.Header.Get("X-Request-Id"))
+		assert.Empty(t, r.Header.Get("X-Hop"))
+		w.Header().Set("Location", "/users/1")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write(body)
+	}))
+	defer upstream.Close()
+
+	req := httptest.NewRequest("PATCH", "/dynamocker/api/serve-mock-api/users/1?a=b", strings.NewReader("payload"))
+	req.Header.Set("X-Request-Id", "abc")
+	req.Header.Set("Connection", "X-Hop")
+	req.Header.Set("X-Hop", "1")
+	res, err := Forward(req, upstream.URL+"/base/", "users/1", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, res.Status)
+	assert.Equal(t, "/users/1", res.Headers.Get("Location"))
+	assert.Equal(t, []byte("payload"), res.Body)
+
+	// the body of the request is still readable
+	body, _ := io.ReadAll(req.Body)
+	assert.Equal(t, "payload", string(body))
+
+	w := httptest.NewRecorder()
+	res.Write(w)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "/users/1", w.Header().Get("Location"))
+	assert.Equal(t, "payload", w.Body.String())
+
+	_, err = Forward(httptest.NewRequest("GET", "/", nil), "http://127.0.0.1:1", "users", nil)
+	assert.NotNil(t, err)
+}
+
+func TestResponseDef(t *testing.T) {
+	res := Response{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": {"application/json; charset=utf-8"}, "Date": {"today"}, "Etag": {"v1"}},
+		Body:    []byte(`[1,2]`),
+	}
+	def := res.ResponseDef()
+	assert.Equal(t, common.BodyTypeJson, def.BodyType)
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, def.Body)
+	assert.Equal(t, map[string]string{"Etag": "v1"}, def.Headers)
+
+	res = Response{Status: http.StatusOK, Headers: http.Header{"Content-Type": {"text/csv"}}, Body: []byte("a,b\n1,2\n")}
+	def = res.ResponseDef()
+	assert.Equal(t, common.BodyTypeText, def.BodyType)
+	assert.Equal(t, "a,b\n1,2\n", def.Body)
+	assert.Equal(t, "text/csv", def.ContentType())
+
+	res = Response{Status: http.StatusOK, Headers: http.Header{}, Body: []byte{0xff, 0x00}}
+	def = res.ResponseDef()
+	assert.Equal(t, common.BodyTypeBase64, def.BodyType)
+	bytes, err := def.BodyBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xff, 0x00}, bytes)
+
+	// invalid json is kept as text
+	res = Response{Status: http.StatusOK, Headers: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{"broken"`)}
+	assert.Equal(t, common.BodyTypeText, res.ResponseDef().BodyType)
+
+	res = Response{Status: http.StatusNoContent, Headers: http.Header{}}
+	def = res.ResponseDef()
+	assert.Nil(t, def.Body)
+	assert.Equal(t, http.StatusNoContent, def.StatusCode())
+}
+
+func TestRecordedMockApi(t *testing.T) {
+	res := Response{Status: http.StatusCreated, Headers: http.Header{}}
+	mockApi, err := RecordedMockApi("POST", "/users/42/orders/", &res)
+	assert.Nil(t, err)
+	assert.Equal(t, "recorded-post-users-42-orders", mockApi.Name)
+	assert.Equal(t, "users/42/orders", mockApi.URL)
+	assert.Equal(t, http.StatusCreated, mockApi.Responses.Post.Status)
+	assert.Nil(t, mockApi.Responses.Get)
+
+	_, err = RecordedMockApi("TRACE", "users", &res)
+	assert.NotNil(t, err)
+}
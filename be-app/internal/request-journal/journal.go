@@ -0,0 +1,181 @@
+package requestjournalpkg
+
+import (
+	"dynamocker/internal/config"
+	requestmatcherpkg "dynamocker/internal/request-matcher"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Entry is a request served by the serve-mock-api endpoint
+type Entry struct {
+	Id        uint64      `json:"id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Method    string      `json:"method"`
+	URL       string      `json:"url"`
+	Path      string      `json:"path"`
+	Headers   http.Header `json:"headers"`
+	Body      string      `json:"body,omitempty"`
+	// set to "base64" if the body is not a valid utf-8 string
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
+	// id and name of the matched mockApi, missing if no mockApi matched
+	MockApiId   string `json:"mockApiId,omitempty"`
+	MockApiName string `json:"mockApiName,omitempty"`
+	// status of the response, 0 if no response was sent (e.g. injected faults)
+	Status     int     `json:"status"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+// Filter selects the entries of the journal. Zero values match any entry
+type Filter struct {
+	Method string
+	// regular expression matched against the full url
+	URL       *regexp.Regexp
+	MockApiId string
+	// only the requests that matched no mockApi
+	Unmatched bool
+	Status    int
+	Since     time.Time
+	// maximum number of entries returned, the most recent ones are kept
+	Limit int
+}
+
+// bounded journal: once full, the oldest entries are discarded
+type journal struct {
+	mu      sync.RWMutex
+	entries []Entry
+	size    int
+	nextId  uint64
+}
+
+var defaultJournal = newJournal(1000)
+
+func newJournal(size int) *journal {
+	return &journal{entries: make([]Entry, 0), size: size, nextId: 1}
+}
+
+// Init sizes the journal according to the configuration, clearing it
+func Init() error {
+	size, err := strconv.Atoi(config.GetJournalSize())
+	if err != nil || size <= 0 {
+		return fmt.Errorf("invalid journal size '%s'", config.GetJournalSize())
+	}
+	defaultJournal = newJournal(size)
+	log.Infof("request journal initiated with size %d", size)
+	return nil
+}
+
+// Record adds the entry to the journal, assigning its id
+func Record(entry Entry) {
+	j := defaultJournal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry.Id = j.nextId
+	j.nextId++
+	j.entries = append(j.entries, entry)
+	if len(j.entries) > j.size {
+		j.entries = j.entries[len(j.entries)-j.size:]
+	}
+}
+
+// List returns the entries matching the filter, in chronological order
+func List(filter Filter) []Entry {
+	j := defaultJournal
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	res := make([]Entry, 0)
+	for _, entry := range j.entries {
+		if filter.matches(&entry) {
+			res = append(res, entry)
+		}
+	}
+	if filter.Limit > 0 && len(res) > filter.Limit {
+		res = res[len(res)-filter.Limit:]
+	}
+	return res
+}
+
+// Clear removes all the entries from the journal
+func Clear() {
+	j := defaultJournal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries = make([]Entry, 0)
+}
+
+func (f *Filter) matches(entry *Entry) bool {
+	if f.Method != "" && f.Method != entry.Method {
+		return false
+	}
+	if f.URL != nil && !f.URL.MatchString(entry.URL) {
+		return false
+	}
+	if f.MockApiId != "" && f.MockApiId != entry.MockApiId {
+		return false
+	}
+	if f.Unmatched && entry.MockApiId != "" {
+		return false
+	}
+	if f.Status != 0 && f.Status != entry.Status {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Timestamp.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// SetBody stores the body, encoding it in base64 if it is binary
+func (e *Entry) SetBody(body []byte) {
+	if utf8.Valid(body) {
+		e.Body = string(body)
+		e.BodyEncoding = ""
+		return
+	}
+	e.Body = base64.StdEncoding.EncodeToString(body)
+	e.BodyEncoding = "base64"
+}
+
+// BodyBytes returns the body as it was received
+func (e *Entry) BodyBytes() []byte {
+	if e.BodyEncoding == "base64" {
+		body, err := base64.StdEncoding.DecodeString(e.Body)
+		if err != nil {
+			return []byte(e.Body)
+		}
+		return body
+	}
+	return []byte(e.Body)
+}
+
+// Request returns the snapshot of the recorded request, to be evaluated by
+// the request matchers
+func (e *Entry) Request() *requestmatcherpkg.Request {
+	req := requestmatcherpkg.Request{
+		Method:  e.Method,
+		Path:    e.Path,
+		Headers: e.Headers,
+		Query:   url.Values{},
+		Cookies: make(map[string]string),
+		Body:    e.BodyBytes(),
+	}
+	if parsed, err := url.Parse(e.URL); err == nil {
+		req.Query = parsed.Query()
+	}
+	for _, cookie := range (&http.Request{Header: e.Headers}).Cookies() {
+		req.Cookies[cookie.Name] = cookie.Value
+	}
+	return &req
+}
@@ -0,0 +1,83 @@
+package requestjournalpkg
+
+import (
+	"net/http"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInit(t *testing.T) {
+	if err := os.Setenv("DYNA_JOURNAL_SIZE", "not a number"); err != nil {
+		t.Fatalf("cannot set env variable: %s", err)
+	}
+	assert.EqualError(t, Init(), "invalid journal size 'not a number'")
+
+	if err := os.Setenv("DYNA_JOURNAL_SIZE", "3"); err != nil {
+		t.Fatalf("cannot set env variable: %s", err)
+	}
+	defer os.Unsetenv("DYNA_JOURNAL_SIZE")
+	assert.Nil(t, Init())
+	assert.Equal(t, 3, defaultJournal.size)
+}
+
+func TestRecordBounded(t *testing.T) {
+	defaultJournal = newJournal(3)
+	for i := 0; i < 5; i++ {
+		Record(Entry{Method: "GET", URL: "/url"})
+	}
+	entries := List(Filter{})
+	assert.Equal(t, 3, len(entries))
+	// the oldest entries have been discarded
+	assert.Equal(t, []uint64{3, 4, 5}, []uint64{entries[0].Id, entries[1].Id, entries[2].Id})
+
+	Clear()
+	assert.Empty(t, List(Filter{}))
+
+	// ids keep growing after clearing the journal
+	Record(Entry{Method: "GET", URL: "/url"})
+	assert.Equal(t, uint64(6), List(Filter{})[0].Id)
+}
+
+func TestListFilter(t *testing.T) {
+	defaultJournal = newJournal(10)
+	now := time.Now()
+	Record(Entry{Method: "GET", URL: "/users/1", MockApiId: "users", Status: http.StatusOK, Timestamp: now.Add(-time.Hour)})
+	Record(Entry{Method: "POST", URL: "/users?x=1", MockApiId: "users", Status: http.StatusCreated, Timestamp: now})
+	Record(Entry{Method: "GET", URL: "/orders/1", MockApiId: "orders", Status: http.StatusOK, Timestamp: now})
+	Record(Entry{Method: "GET", URL: "/unknown", Status: http.StatusNotFound, Timestamp: now})
+
+	ids := func(filter Filter) []uint64 {
+		res := make([]uint64, 0)
+		for _, entry := range List(filter) {
+			res = append(res, entry.Id)
+		}
+		return res
+	}
+	assert.Equal(t, []uint64{1, 2, 3, 4}, ids(Filter{}))
+	assert.Equal(t, []uint64{1, 3, 4}, ids(Filter{Method: "GET"}))
+	assert.Equal(t, []uint64{1, 2}, ids(Filter{URL: regexp.MustCompile("^/users")}))
+	assert.Equal(t, []uint64{3}, ids(Filter{MockApiId: "orders"}))
+	assert.Equal(t, []uint64{4}, ids(Filter{Unmatched: true}))
+	assert.Equal(t, []uint64{2}, ids(Filter{Status: http.StatusCreated}))
+	assert.Equal(t, []uint64{2, 3, 4}, ids(Filter{Since: now.Add(-time.Minute)}))
+	assert.Equal(t, []uint64{3, 4}, ids(Filter{Limit: 2}))
+	assert.Equal(t, []uint64{1, 3}, ids(Filter{Method: "GET", Status: http.StatusOK}))
+}
+
+func TestBody(t *testing.T) {
+	var entry Entry
+	entry.SetBody([]byte(`{"text":true}`))
+	assert.Equal(t, `{"text":true}`, entry.Body)
+	assert.Equal(t, "", entry.BodyEncoding)
+	assert.Equal(t, []byte(`{"text":true}`), entry.BodyBytes())
+
+	binary := []byte{0xff, 0xfe, 0x00}
+	entry.SetBody(binary)
+	assert.Equal(t, "//4A", entry.Body)
+	assert.Equal(t, "base64", entry.BodyEncoding)
+	assert.Equal(t, binary, entry.BodyBytes())
+}
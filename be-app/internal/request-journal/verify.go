@@ -0,0 +1,127 @@
+package requestjournalpkg
+
+import (
+	"dynamocker/internal/common"
+	requestmatcherpkg "dynamocker/internal/request-matcher"
+	urlpatternpkg "dynamocker/internal/url-pattern"
+	"fmt"
+	"sort"
+)
+
+// maximum number of near misses returned by a failed verification
+const maxNearMisses = 5
+
+// Verification describes the requests expected to be found in the journal.
+// Method and URL are optional, the URL uses the same patterns of the
+// MockApis. If no count is set, at least one request is expected.
+type Verification struct {
+	Method string                `json:"method,omitempty"`
+	URL    string                `json:"url,omitempty"`
+	Match  common.RequestMatcher `json:"match"`
+	Count  ExpectedCount         `json:"count"`
+}
+
+// ExpectedCount sets the number of matching requests. Only one of its fields
+// should be set
+type ExpectedCount struct {
+	Exactly *int `json:"exactly,omitempty"`
+	AtLeast *int `json:"atLeast,omitempty"`
+	AtMost  *int `json:"atMost,omitempty"`
+}
+
+// VerificationResult reports whether the verification passed. If it failed,
+// the recorded requests closest to the expected one are listed, together with
+// the reasons why they don't match
+type VerificationResult struct {
+	Passed     bool       `json:"passed"`
+	Count      int        `json:"count"`
+	Expected   string     `json:"expected"`
+	Matched    []uint64   `json:"matched"`
+	NearMisses []NearMiss `json:"nearMisses,omitempty"`
+}
+
+type NearMiss struct {
+	Request    Entry    `json:"request"`
+	Mismatches []string `json:"mismatches"`
+}
+
+// Verify counts the recorded requests satisfying the verification
+func Verify(verification Verification) (VerificationResult, error) {
+	var pattern *urlpatternpkg.Pattern
+	if verification.URL != "" {
+		var err error
+		if pattern, err = urlpatternpkg.Compile(verification.URL); err != nil {
+			return VerificationResult{}, err
+		}
+	}
+	if err := requestmatcherpkg.Check(verification.Match); err != nil {
+		return VerificationResult{}, err
+	}
+
+	res := VerificationResult{Matched: make([]uint64, 0), Expected: verification.Count.String()}
+	nearMisses := make([]NearMiss, 0)
+	for _, entry := range List(Filter{}) {
+		mismatches := make([]string, 0)
+		if verification.Method != "" && verification.Method != entry.Method {
+			mismatches = append(mismatches, fmt.Sprintf("method should be '%s', found '%s'", verification.Method, entry.Method))
+		}
+		req := entry.Request()
+		if pattern != nil {
+			params, match := pattern.Match(entry.Path)
+			if !match {
+				mismatches = append(mismatches, fmt.Sprintf("url should match '%s', found '%s'", verification.URL, entry.Path))
+			}
+			req.PathParams = params
+		}
+		mismatches = append(mismatches, requestmatcherpkg.Mismatches(verification.Match, req)...)
+		if len(mismatches) == 0 {
+			res.Matched = append(res.Matched, entry.Id)
+		} else {
+			nearMisses = append(nearMisses, NearMiss{Request: entry, Mismatches: mismatches})
+		}
+	}
+	res.Count = len(res.Matched)
+	res.Passed = verification.Count.check(res.Count)
+
+	if !res.Passed {
+		// the fewer the mismatches, the closer the request
+		sort.SliceStable(nearMisses, func(i, j int) bool {
+			return len(nearMisses[i].Mismatches) < len(nearMisses[j].Mismatches)
+		})
+		if len(nearMisses) > maxNearMisses {
+			nearMisses = nearMisses[:maxNearMisses]
+		}
+		res.NearMisses = nearMisses
+	}
+	return res, nil
+}
+
+func (c ExpectedCount) check(count int) bool {
+	switch {
+	case c.Exactly != nil:
+		return count == *c.Exactly
+	case c.AtLeast != nil && c.AtMost != nil:
+		return count >= *c.AtLeast && count <= *c.AtMost
+	case c.AtLeast != nil:
+		return count >= *c.AtLeast
+	case c.AtMost != nil:
+		return count <= *c.AtMost
+	default:
+		return count >= 1
+	}
+}
+
+func (c ExpectedCount) String() string {
+	switch {
+	case c.Exactly != nil:
+		return fmt.Sprintf("exactly %d", *c.Exactly)
+	case c.AtLeast != nil && c.AtMost != nil:
+		return fmt.Sprintf("between %d and %d", *c.AtLeast, *c.AtMost)
+	case c.AtLeast != nil:
+		return fmt.Sprintf("at least %d", *c.AtLeast)
+	case c.AtMost != nil:
+		return fmt.Sprintf("at most %d", *c.AtMost)
+	default:
+		return "at least 1"
+	}
+}
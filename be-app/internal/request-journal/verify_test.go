@@ -0,0 +1,67 @@
+package requestjournalpkg
+
+import (
+	"dynamocker/internal/common"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	defaultJournal = newJournal(10)
+	record := func(method string, url string, path string, body string) {
+		entry := Entry{Method: method, URL: url, Path: path, Headers: http.Header{"Cookie": {"session=abc"}}}
+		entry.SetBody([]byte(body))
+		Record(entry)
+	}
+	record("POST", "/payments?currency=EUR", "payments", `{"amount":10}`)
+	record("POST", "/payments?currency=USD", "payments", `{"amount":20}`)
+	record("GET", "/payments/1", "payments/1", "")
+
+	one, two := 1, 2
+	amountIs10 := common.RequestMatcher{Body: []common.BodyMatcher{{JsonPath: "$.amount", ValueMatcher: common.ValueMatcher{EqualTo: 10}}}}
+
+	res, err := Verify(Verification{Method: "POST", URL: "payments", Match: amountIs10, Count: ExpectedCount{Exactly: &one}})
+	assert.Nil(t, err)
+	assert.True(t, res.Passed)
+	assert.Equal(t, 1, res.Count)
+	assert.Equal(t, []uint64{1}, res.Matched)
+	assert.Equal(t, "exactly 1", res.Expected)
+	assert.Empty(t, res.NearMisses)
+
+	// path parameters, query and cookies are matched as well
+	res, err = Verify(Verification{
+		URL: "payments/{id}",
+		Match: common.RequestMatcher{
+			PathParams: map[string]common.ValueMatcher{"id": {EqualTo: "1"}},
+			Cookies:    map[string]common.ValueMatcher{"session": {EqualTo: "abc"}},
+		},
+	})
+	assert.Nil(t, err)
+	assert.True(t, res.Passed)
+	assert.Equal(t, "at least 1", res.Expected)
+	res, err = Verify(Verification{Match: common.RequestMatcher{Query: map[string]common.ValueMatcher{"currency": {Matches: "^(EUR|USD)$"}}}, Count: ExpectedCount{AtLeast: &two, AtMost: &two}})
+	assert.Nil(t, err)
+	assert.True(t, res.Passed)
+
+	// the closest requests come first
+	res, err = Verify(Verification{Method: "POST", URL: "payments", Match: amountIs10, Count: ExpectedCount{AtLeast: &two}})
+	assert.Nil(t, err)
+	assert.False(t, res.Passed)
+	assert.Equal(t, 1, res.Count)
+	assert.Equal(t, 2, len(res.NearMisses))
+	assert.Equal(t, uint64(2), res.NearMisses[0].Request.Id)
+	assert.Equal(t, []string{"body field '$.amount' should be equal to '10', found '20'"}, res.NearMisses[0].Mismatches)
+	assert.Equal(t, uint64(3), res.NearMisses[1].Request.Id)
+	assert.Equal(t, 3, len(res.NearMisses[1].Mismatches))
+
+	res, err = Verify(Verification{Method: "DELETE", Count: ExpectedCount{AtMost: &one}})
+	assert.Nil(t, err)
+	assert.True(t, res.Passed)
+
+	_, err = Verify(Verification{URL: "payments/**/x"})
+	assert.NotNil(t, err)
+	_, err = Verify(Verification{Match: common.RequestMatcher{Body: []common.BodyMatcher{{JsonPath: "amount"}}}})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,92 @@
+package requestmatcherpkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EvaluateJsonPath evaluates a simple JSONPath expression on the decoded
+// json. Supported expressions are made of keys and indexes, e.g.
+//
+//	$.user.name
+//	$.items[0].id
+//	$['user name']
+//
+// It returns the selected value and false if the path does not exist.
+func EvaluateJsonPath(path string, document interface{}) (interface{}, bool, error) {
+	steps, err := parseJsonPath(path)
+	if err != nil {
+		return nil, false, err
+	}
+	current := document
+	for _, step := range steps {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			if step.isIndex {
+				return nil, false, nil
+			}
+			value, found := node[step.key]
+			if !found {
+				return nil, false, nil
+			}
+			current = value
+		case []interface{}:
+			if !step.isIndex || step.index < 0 || step.index >= len(node) {
+				return nil, false, nil
+			}
+			current = node[step.index]
+		default:
+			return nil, false, nil
+		}
+	}
+	return current, true, nil
+}
+
+type jsonPathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func parseJsonPath(path string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid jsonPath '%s': it must start with '$'", path)
+	}
+	steps := make([]jsonPathStep, 0)
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("invalid jsonPath '%s': empty key", path)
+			}
+			steps = append(steps, jsonPathStep{key: key})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid jsonPath '%s': missing ']'", path)
+			}
+			content := rest[1:end]
+			if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+				steps = append(steps, jsonPathStep{key: content[1 : len(content)-1]})
+			} else {
+				index, err := strconv.Atoi(content)
+				if err != nil {
+					return nil, fmt.Errorf("invalid jsonPath '%s': invalid index '%s'", path, content)
+				}
+				steps = append(steps, jsonPathStep{index: index, isIndex: true})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid jsonPath '%s': unexpected character '%c'", path, rest[0])
+		}
+	}
+	return steps, nil
+}
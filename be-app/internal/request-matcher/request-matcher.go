@@ -0,0 +1,172 @@
+package requestmatcherpkg
+
+import (
+	"bytes"
+	"dynamocker/internal/common"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// Request is the snapshot of an incoming request used to evaluate the
+// matchers
+type Request struct {
+	Method     string
+	Path       string
+	PathParams map[string]string
+	Query      url.Values
+	Headers    http.Header
+	Cookies    map[string]string
+	Body       []byte
+}
+
+// FromHttpRequest reads the request, body included. The body of the http
+// request is restored so that it can be read again.
+func FromHttpRequest(r *http.Request, path string, pathParams map[string]string) (*Request, error) {
+	req := Request{
+		Method:     r.Method,
+		Path:       path,
+		PathParams: pathParams,
+		Query:      r.URL.Query(),
+		Headers:    r.Header,
+		Cookies:    make(map[string]string),
+	}
+	for _, cookie := range r.Cookies() {
+		req.Cookies[cookie.Name] = cookie.Value
+	}
+	if r.Body != nil {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading request body: %s", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		req.Body = body
+	}
+	return &req, nil
+}
+
+// Matches returns true if the request satisfies all the conditions
+func Matches(matcher common.RequestMatcher, req *Request) bool {
+	return len(Mismatches(matcher, req)) == 0
+}
+
+// Mismatches returns the description of the conditions not satisfied by the
+// request. The request matches if the list is empty.
+func Mismatches(matcher common.RequestMatcher, req *Request) []string {
+	mismatches := make([]string, 0)
+	for name, valueMatcher := range matcher.PathParams {
+		value, found := req.PathParams[name]
+		mismatches = appendMismatch(mismatches, "path parameter '"+name+"'", valueMatcher, value, found)
+	}
+	for name, valueMatcher := range matcher.Query {
+		_, found := req.Query[name]
+		mismatches = appendMismatch(mismatches, "query parameter '"+name+"'", valueMatcher, req.Query.Get(name), found)
+	}
+	for name, valueMatcher := range matcher.Headers {
+		_, found := req.Headers[http.CanonicalHeaderKey(name)]
+		mismatches = appendMismatch(mismatches, "header '"+name+"'", valueMatcher, req.Headers.Get(name), found)
+	}
+	for name, valueMatcher := range matcher.Cookies {
+		value, found := req.Cookies[name]
+		mismatches = appendMismatch(mismatches, "cookie '"+name+"'", valueMatcher, value, found)
+	}
+	for _, bodyMatcher := range matcher.Body {
+		if bodyMatcher.JsonPath == "" {
+			mismatches = appendMismatch(mismatches, "body", bodyMatcher.ValueMatcher, string(req.Body), len(req.Body) > 0)
+			continue
+		}
+		var body interface{}
+		if err := json.Unmarshal(req.Body, &body); err != nil {
+			mismatches = append(mismatches, fmt.Sprintf("body is not a valid json, required by '%s'", bodyMatcher.JsonPath))
+			continue
+		}
+		value, found, err := EvaluateJsonPath(bodyMatcher.JsonPath, body)
+		if err != nil {
+			mismatches = append(mismatches, err.Error())
+			continue
+		}
+		mismatches = appendMismatch(mismatches, "body field '"+bodyMatcher.JsonPath+"'", bodyMatcher.ValueMatcher, toString(value), found)
+	}
+	sort.Strings(mismatches)
+	return mismatches
+}
+
+// Check returns an error if any JSONPath of the matcher is malformed
+func Check(matcher common.RequestMatcher) error {
+	for _, bodyMatcher := range matcher.Body {
+		if bodyMatcher.JsonPath == "" {
+			continue
+		}
+		if _, err := parseJsonPath(bodyMatcher.JsonPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendMismatch(mismatches []string, subject string, matcher common.ValueMatcher, value string, found bool) []string {
+	if mismatch := matchValue(matcher, value, found); mismatch != "" {
+		return append(mismatches, subject+" "+mismatch)
+	}
+	return mismatches
+}
+
+// returns the reason why the value does not match, or an empty string
+func matchValue(matcher common.ValueMatcher, value string, found bool) string {
+	if matcher.Absent {
+		if found {
+			return fmt.Sprintf("should be absent, found '%s'", value)
+		}
+		return ""
+	}
+	if !found {
+		return "is missing"
+	}
+	if matcher.EqualTo != nil {
+		if expected := toString(matcher.EqualTo); expected != value {
+			return fmt.Sprintf("should be equal to '%s', found '%s'", expected, value)
+		}
+	}
+	if matcher.Matches != "" {
+		regex, err := compileRegex(matcher.Matches)
+		if err != nil {
+			return fmt.Sprintf("has an invalid regex '%s': %s", matcher.Matches, err)
+		}
+		if !regex.MatchString(value) {
+			return fmt.Sprintf("should match '%s', found '%s'", matcher.Matches, value)
+		}
+	}
+	return ""
+}
+
+// strings are returned as they are, any other value as json
+func toString(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(bytes)
+}
+
+// compiled regular expressions, cached by expression
+var regexCache sync.Map
+
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	if regex, found := regexCache.Load(expr); found {
+		return regex.(*regexp.Regexp), nil
+	}
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(expr, regex)
+	return regex, nil
+}
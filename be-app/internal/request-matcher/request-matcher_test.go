@@ -0,0 +1,85 @@
+package requestmatcherpkg
+
+import (
+	"dynamocker/internal/common"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func dummyRequest(t *testing.T) *Request {
+	r := httptest.NewRequest("POST", "/users/42/orders?status=active&page=2", strings.NewReader(`{"user":{"name":"john","ids":[4,5]},"amount":10,"valid":true}`))
+	r.Header.Set("Authorization", "Bearer token")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "abc123"})
+	req, err := FromHttpRequest(r, "users/42/orders", map[string]string{"id": "42"})
+	if err != nil {
+		t.Fatalf("error while reading the request: %s", err)
+	}
+	return req
+}
+
+func dummyMatcher(t *testing.T, matcherJson string) common.RequestMatcher {
+	var matcher common.RequestMatcher
+	if err := json.Unmarshal([]byte(matcherJson), &matcher); err != nil {
+		t.Fatalf("error while unmarshaling the matcher: %s", err)
+	}
+	return matcher
+}
+
+func TestMatches(t *testing.T) {
+	req := dummyRequest(t)
+
+	matching := []string{
+		`{}`,
+		`{"pathParams":{"id":{"equalTo":"42"}}}`,
+		`{"query":{"status":{"equalTo":"active"},"page":{"matches":"^[0-9]+$"},"sort":{"absent":true}}}`,
+		`{"headers":{"authorization":{"matches":"^Bearer "}}}`,
+		`{"cookies":{"session":{}}}`,
+		`{"body":[{"jsonPath":"$.user.name","equalTo":"john"},{"jsonPath":"$.amount","equalTo":10},{"jsonPath":"$.valid","equalTo":true}]}`,
+		`{"body":[{"jsonPath":"$.user.ids[1]","equalTo":5},{"jsonPath":"$['user']['name']","matches":"^jo"},{"jsonPath":"$.missing","absent":true}]}`,
+		`{"body":[{"matches":"\"amount\":10"}]}`,
+	}
+	for _, matcherJson := range matching {
+		assert.True(t, Matches(dummyMatcher(t, matcherJson), req), matcherJson)
+	}
+
+	notMatching := map[string][]string{
+		`{"pathParams":{"id":{"equalTo":"43"}}}`:                    {"path parameter 'id' should be equal to '43', found '42'"},
+		`{"query":{"status":{"equalTo":"archived"}}}`:               {"query parameter 'status' should be equal to 'archived', found 'active'"},
+		`{"query":{"page":{"absent":true}}}`:                        {"query parameter 'page' should be absent, found '2'"},
+		`{"headers":{"X-Request-Id":{}}}`:                           {"header 'X-Request-Id' is missing"},
+		`{"cookies":{"session":{"matches":"^[0-9]+$"}}}`:            {"cookie 'session' should match '^[0-9]+$', found 'abc123'"},
+		`{"body":[{"jsonPath":"$.amount","equalTo":11}]}`:           {"body field '$.amount' should be equal to '11', found '10'"},
+		`{"body":[{"jsonPath":"$.user.ids[2]"}]}`:                   {"body field '$.user.ids[2]' is missing"},
+		`{"body":[{"jsonPath":"user"}]}`:                            {"invalid jsonPath 'user': it must start with '$'"},
+		`{"query":{"status":{"equalTo":"x"},"page":{"equalTo":1}}}`: {"query parameter 'page' should be equal to '1', found '2'", "query parameter 'status' should be equal to 'x', found 'active'"},
+	}
+	for matcherJson, expected := range notMatching {
+		matcher := dummyMatcher(t, matcherJson)
+		assert.False(t, Matches(matcher, req), matcherJson)
+		assert.Equal(t, expected, Mismatches(matcher, req), matcherJson)
+	}
+}
+
+func TestFromHttpRequestRestoresBody(t *testing.T) {
+	r := httptest.NewRequest("POST", "/users", strings.NewReader("body"))
+	req, err := FromHttpRequest(r, "users", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("body"), req.Body)
+
+	// the body can be read again
+	req, err = FromHttpRequest(r, "users", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("body"), req.Body)
+}
+
+func TestCheck(t *testing.T) {
+	assert.Nil(t, Check(dummyMatcher(t, `{"body":[{"jsonPath":"$.a.b[0]['c d']"},{"matches":".*"}]}`)))
+	assert.EqualError(t, Check(dummyMatcher(t, `{"body":[{"jsonPath":"$.a[b]"}]}`)), "invalid jsonPath '$.a[b]': invalid index 'b'")
+	assert.EqualError(t, Check(dummyMatcher(t, `{"body":[{"jsonPath":"$.a..b"}]}`)), "invalid jsonPath '$.a..b': empty key")
+	assert.EqualError(t, Check(dummyMatcher(t, `{"body":[{"jsonPath":"$.a[0"}]}`)), "invalid jsonPath '$.a[0': missing ']'")
+}
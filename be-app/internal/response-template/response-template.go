@@ -0,0 +1,250 @@
+package responsetemplatepkg
+
+import (
+	"bytes"
+	"crypto/rand"
+	"dynamocker/internal/common"
+	requestmatcherpkg "dynamocker/internal/request-matcher"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// data available to the templates, e.g.
+//
+//	{{.PathParams.id}}
+//	{{.Query.status}}
+//	{{.Header "X-Request-Id"}}
+//	{{.JsonPath "$.user.name"}}
+type templateData struct {
+	Method     string
+	Path       string
+	PathParams map[string]string
+	// first value of each query parameter
+	Query map[string]string
+	// first value of each header, by canonical name
+	Headers map[string]string
+	Cookies map[string]string
+	// decoded json body, an empty one if the body is missing or is not a json
+	Body    interface{}
+	RawBody string
+}
+
+// body of the requests without a json body: its fields, at any depth, are
+// empty, so that e.g. {{.Body.user.name}} renders an empty string
+type emptyBody map[string]emptyBody
+
+func (emptyBody) String() string {
+	return ""
+}
+
+// Header returns the value of the header, whatever the case of the name
+func (d templateData) Header(name string) string {
+	return d.Headers[http.CanonicalHeaderKey(name)]
+}
+
+// JsonPath returns the field of the json body selected by the path
+func (d templateData) JsonPath(path string) (interface{}, error) {
+	value, _, err := requestmatcherpkg.EvaluateJsonPath(path, d.Body)
+	return value, err
+}
+
+// helpers available to the templates, e.g.
+//
+//	{{now}}, {{now "2006-01-02"}}
+//	{{uuid}}
+//	{{randomInt 1 100}}
+//	{{base64 "text"}}, {{base64Decode "dGV4dA=="}}
+var funcs = template.FuncMap{
+	"now": func(layout ...string) string {
+		if len(layout) > 0 {
+			return time.Now().Format(layout[0])
+		}
+		return time.Now().Format(time.RFC3339)
+	},
+	"uuid": NewUuid,
+	"randomInt": func(min, max int) (int, error) {
+		if max < min {
+			return 0, fmt.Errorf("randomInt: max %d is lower than min %d", max, min)
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(max-min+1)))
+		if err != nil {
+			return 0, err
+		}
+		return min + int(n.Int64()), nil
+	},
+	"base64": func(text string) string {
+		return base64.StdEncoding.EncodeToString([]byte(text))
+	},
+	"base64Decode": func(text string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(text)
+		return string(decoded), err
+	},
+}
+
+// NewUuid generates a random (version 4) uuid
+func NewUuid() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("cannot generate random uuid: %s", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// Check parses the templates of the response, returning the first error
+func Check(response *common.ResponseDef) error {
+	if !response.Template {
+		return nil
+	}
+	return walkTemplates(response, func(text string) (string, error) {
+		_, err := template.New("").Funcs(funcs).Option("missingkey=zero").Parse(text)
+		return text, err
+	})
+}
+
+// Render returns a copy of the response whose headers and body have been
+// rendered using the request. Responses not flagged as templates are
+// returned as they are.
+func Render(response *common.ResponseDef, req *requestmatcherpkg.Request) (*common.ResponseDef, error) {
+	if !response.Template {
+		return response, nil
+	}
+	data := newTemplateData(req)
+	rendered := *response
+	rendered.Headers = make(map[string]string)
+	for key, value := range response.Headers {
+		rendered.Headers[key] = value
+	}
+	rendered.Body = copyJson(response.Body)
+	err := walkTemplates(&rendered, func(text string) (string, error) {
+		return renderString(text, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rendered, nil
+}
+
+// apply the function to all the templates of the response: header values,
+// text bodies and the strings contained in json bodies. Binary bodies are
+// never rendered.
+func walkTemplates(response *common.ResponseDef, apply func(string) (string, error)) error {
+	for key, value := range response.Headers {
+		text, err := apply(value)
+		if err != nil {
+			return fmt.Errorf("header '%s': %s", key, err)
+		}
+		response.Headers[key] = text
+	}
+	switch response.BodyType {
+	case common.BodyTypeBase64:
+		return nil
+	case common.BodyTypeText:
+		if body, ok := response.Body.(string); ok {
+			text, err := apply(body)
+			if err != nil {
+				return fmt.Errorf("body: %s", err)
+			}
+			response.Body = text
+		}
+		return nil
+	default:
+		body, err := walkJson(response.Body, apply)
+		if err != nil {
+			return fmt.Errorf("body: %s", err)
+		}
+		response.Body = body
+		return nil
+	}
+}
+
+func walkJson(value interface{}, apply func(string) (string, error)) (interface{}, error) {
+	switch node := value.(type) {
+	case string:
+		return apply(node)
+	case map[string]interface{}:
+		for key, child := range node {
+			rendered, err := walkJson(child, apply)
+			if err != nil {
+				return nil, err
+			}
+			node[key] = rendered
+		}
+	case []interface{}:
+		for i, child := range node {
+			rendered, err := walkJson(child, apply)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = rendered
+		}
+	}
+	return value, nil
+}
+
+// deep copy of the decoded json, so that rendering does not modify the mock
+func copyJson(value interface{}) interface{} {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(node))
+		for key, child := range node {
+			copied[key] = copyJson(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(node))
+		for i, child := range node {
+			copied[i] = copyJson(child)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+func renderString(text string, data templateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("").Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func newTemplateData(req *requestmatcherpkg.Request) templateData {
+	data := templateData{
+		Method:     req.Method,
+		Path:       req.Path,
+		PathParams: req.PathParams,
+		Query:      make(map[string]string),
+		Headers:    make(map[string]string),
+		Cookies:    req.Cookies,
+		RawBody:    string(req.Body),
+	}
+	for key := range req.Query {
+		data.Query[key] = req.Query.Get(key)
+	}
+	for key := range req.Headers {
+		data.Headers[http.CanonicalHeaderKey(key)] = req.Headers.Get(key)
+	}
+	var body interface{}
+	if json.Unmarshal(req.Body, &body) == nil {
+		data.Body = body
+	} else {
+		data.Body = emptyBody{}
+	}
+	return data
+}
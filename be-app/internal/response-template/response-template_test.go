@@ -0,0 +1,102 @@
+package responsetemplatepkg
+
+import (
+	"dynamocker/internal/common"
+	requestmatcherpkg "dynamocker/internal/request-matcher"
+	"encoding/json"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func dummyRequest(t *testing.T) *requestmatcherpkg.Request {
+	r := httptest.NewRequest("POST", "/users/42?status=active", strings.NewReader(`{"user":{"name":"john"},"ids":[4,5]}`))
+	r.Header.Set("X-Correlation-Id", "corr-1")
+	req, err := requestmatcherpkg.FromHttpRequest(r, "users/42", map[string]string{"id": "42"})
+	if err != nil {
+		t.Fatalf("error while reading the request: %s", err)
+	}
+	return req
+}
+
+func dummyResponse(t *testing.T, responseJson string) *common.ResponseDef {
+	var response common.ResponseDef
+	if err := json.Unmarshal([]byte(responseJson), &response); err != nil {
+		t.Fatalf("error while unmarshaling the response: %s", err)
+	}
+	return &response
+}
+
+func TestRender(t *testing.T) {
+	response := dummyResponse(t, `{
+		"template": true,
+		"headers": {"X-Correlation-Id": "{{.Header \"x-correlation-id\"}}", "Location": "/users/{{.PathParams.id}}"},
+		"body": {"id": "{{.PathParams.id}}", "status": "{{.Query.status}}", "name": "{{.Body.user.name}}", "first": "{{.JsonPath \"$.ids[0]\"}}", "list": ["{{.Method}}", "{{.Path}}", 3], "missing": "{{.Query.missing}}"}
+	}`)
+	rendered, err := Render(response, dummyRequest(t))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"X-Correlation-Id": "corr-1", "Location": "/users/42"}, rendered.Headers)
+	assert.Equal(t, map[string]interface{}{
+		"id":      "42",
+		"status":  "active",
+		"name":    "john",
+		"first":   "4",
+		"list":    []interface{}{"POST", "users/42", float64(3)},
+		"missing": "",
+	}, rendered.Body)
+
+	// the original response is not modified
+	assert.Equal(t, "{{.PathParams.id}}", response.Body.(map[string]interface{})["id"])
+	assert.Equal(t, "/users/{{.PathParams.id}}", response.Headers["Location"])
+}
+
+func TestRenderWithoutJsonBody(t *testing.T) {
+	response := dummyResponse(t, `{
+		"template": true,
+		"body": {"id": "{{.Body.id}}", "name": "{{.Body.user.name}}", "known": "{{if .Body.id}}yes{{else}}no{{end}}", "raw": "{{.RawBody}}"}
+	}`)
+	for _, body := range []string{"", "id=42"} {
+		r := httptest.NewRequest("POST", "/users", strings.NewReader(body))
+		req, err := requestmatcherpkg.FromHttpRequest(r, "users", nil)
+		if err != nil {
+			t.Fatalf("error while reading the request: %s", err)
+		}
+		rendered, err := Render(response, req)
+		assert.Nil(t, err, body)
+		assert.Equal(t, map[string]interface{}{"id": "", "name": "", "known": "no", "raw": body}, rendered.Body, body)
+	}
+}
+
+func TestRenderHelpers(t *testing.T) {
+	response := dummyResponse(t, `{
+		"template": true,
+		"bodyType": "text",
+		"body": "{{uuid}}|{{randomInt 5 7}}|{{base64 \"text\"}}|{{base64Decode \"dGV4dA==\"}}|{{now \"2006\"}}"
+	}`)
+	rendered, err := Render(response, dummyRequest(t))
+	assert.Nil(t, err)
+	parts := strings.Split(rendered.Body.(string), "|")
+	assert.Regexp(t, regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"), parts[0])
+	assert.Contains(t, []string{"5", "6", "7"}, parts[1])
+	assert.Equal(t, "dGV4dA==", parts[2])
+	assert.Equal(t, "text", parts[3])
+	assert.Equal(t, time.Now().Format("2006"), parts[4])
+}
+
+func TestRenderNotTemplate(t *testing.T) {
+	response := dummyResponse(t, `{"body": {"id": "{{.PathParams.id}}"}}`)
+	rendered, err := Render(response, dummyRequest(t))
+	assert.Nil(t, err)
+	assert.Equal(t, response, rendered)
+}
+
+func TestCheck(t *testing.T) {
+	assert.Nil(t, Check(dummyResponse(t, `{"template": true, "body": {"id": "{{.PathParams.id}}"}}`)))
+	assert.Nil(t, Check(dummyResponse(t, `{"body": {"id": "{{.PathParams.id"}}`)))
+	assert.ErrorContains(t, Check(dummyResponse(t, `{"template": true, "body": {"id": "{{.PathParams.id"}}`)), "body: template: :1: unclosed action")
+	assert.ErrorContains(t, Check(dummyResponse(t, `{"template": true, "headers": {"X-Id": "{{unknown}}"}}`)), "header 'X-Id': template: :1: function \"unknown\" not defined")
+}
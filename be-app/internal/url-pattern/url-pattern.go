@@ -0,0 +1,215 @@
+package urlpatternpkg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// kind of the segments of the pattern. The higher the value, the more
+// specific the segment
+type segmentKind int
+
+const (
+	// '**': matches all the remaining segments, even none
+	catchAllSegment segmentKind = iota
+	// '*': matches exactly one segment
+	wildcardSegment
+	// '{name}': matches exactly one segment, captured as 'name'
+	paramSegment
+	// '{name:regex}': matches one segment satisfying the regex
+	regexSegment
+	// matches exactly the same string
+	literalSegment
+)
+
+type segment struct {
+	kind  segmentKind
+	value string
+	name  string
+	expr  string
+	regex *regexp.Regexp
+}
+
+// Pattern is the compiled form of the url of a MockApi, e.g.
+//
+//	users/{id}/orders
+//	users/{id:[0-9]+}
+//	files/*/content
+//	static/**
+type Pattern struct {
+	raw      string
+	segments []segment
+}
+
+// Compile parses the url of a MockApi. Leading and trailing slashes are ignored
+func Compile(url string) (*Pattern, error) {
+	pattern := Pattern{raw: url}
+	parts := splitPath(url)
+	for i, part := range parts {
+		seg, err := parseSegment(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid segment '%s' in url '%s': %s", part, url, err)
+		}
+		if seg.kind == catchAllSegment && i != len(parts)-1 {
+			return nil, fmt.Errorf("'**' must be the last segment of the url '%s'", url)
+		}
+		pattern.segments = append(pattern.segments, seg)
+	}
+	return &pattern, nil
+}
+
+func parseSegment(part string) (segment, error) {
+	switch {
+	case part == "**":
+		return segment{kind: catchAllSegment}, nil
+	case part == "*":
+		return segment{kind: wildcardSegment}, nil
+	case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+		name, expr, hasRegex := strings.Cut(part[1:len(part)-1], ":")
+		if name == "" {
+			return segment{}, fmt.Errorf("parameter name is empty")
+		}
+		if !hasRegex {
+			return segment{kind: paramSegment, name: name}, nil
+		}
+		regex, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return segment{}, err
+		}
+		return segment{kind: regexSegment, name: name, expr: expr, regex: regex}, nil
+	case strings.ContainsAny(part, "{}"):
+		return segment{}, fmt.Errorf("parameters must take the whole segment")
+	default:
+		return segment{kind: literalSegment, value: part}, nil
+	}
+}
+
+// Match checks whether the path matches the pattern and returns the named
+// parameters captured from the path
+func (p *Pattern) Match(path string) (map[string]string, bool) {
+	parts := splitPath(path)
+	params := make(map[string]string)
+	for i, seg := range p.segments {
+		if seg.kind == catchAllSegment {
+			return params, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		switch seg.kind {
+		case literalSegment:
+			if parts[i] != seg.value {
+				return nil, false
+			}
+		case regexSegment:
+			if !seg.regex.MatchString(parts[i]) {
+				return nil, false
+			}
+			params[seg.name] = parts[i]
+		case paramSegment:
+			params[seg.name] = parts[i]
+		}
+	}
+	if len(parts) != len(p.segments) {
+		return nil, false
+	}
+	return params, true
+}
+
+// String returns the url the pattern was compiled from
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// Key returns the pattern in a normalized form, shared by the patterns
+// matching the same paths: the slashes around the url are dropped and the
+// parameters without regex are written as '*', e.g. 'users/*' for both
+// '/users/{id}/' and 'users/{userId}'
+func (p *Pattern) Key() string {
+	parts := make([]string, 0, len(p.segments))
+	for _, seg := range p.segments {
+		switch seg.kind {
+		case literalSegment:
+			parts = append(parts, seg.value)
+		case catchAllSegment:
+			parts = append(parts, "**")
+		case wildcardSegment, paramSegment:
+			parts = append(parts, "*")
+		case regexSegment:
+			parts = append(parts, "{:"+seg.expr+"}")
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Key returns the key of the pattern of the url, see Pattern.Key. If the url is
+// not a valid pattern, it is returned without the slashes around it
+func Key(url string) string {
+	pattern, err := Compile(url)
+	if err != nil {
+		return strings.Trim(url, "/")
+	}
+	return pattern.Key()
+}
+
+// Param is a segment of the pattern which is not a literal
+type Param struct {
+	Name string
+	// regular expression the segment must match, empty if any value is accepted
+	Regex string
+	// true for the '**' segment, matching any number of segments
+	CatchAll bool
+}
+
+// Template returns the url with a '{name}' placeholder for each segment which
+// is not a literal, together with the description of those segments. The
+// wildcards are named 'wildcard1', 'wildcard2'... and '**' is named 'path'.
+func (p *Pattern) Template() (string, []Param) {
+	parts := make([]string, 0, len(p.segments))
+	params := make([]Param, 0)
+	wildcards := 0
+	for _, seg := range p.segments {
+		var param Param
+		switch seg.kind {
+		case literalSegment:
+			parts = append(parts, seg.value)
+			continue
+		case catchAllSegment:
+			param = Param{Name: "path", CatchAll: true}
+		case wildcardSegment:
+			wildcards++
+			param = Param{Name: fmt.Sprintf("wildcard%d", wildcards)}
+		default:
+			param = Param{Name: seg.name, Regex: seg.expr}
+		}
+		parts = append(parts, "{"+param.Name+"}")
+		params = append(params, param)
+	}
+	return strings.Join(parts, "/"), params
+}
+
+// MoreSpecific returns true if p is more specific than other. Segments are
+// compared from left to right: literals win over regex parameters, which win
+// over parameters, then over '*' and finally over '**'. If all the segments
+// are equally specific, the longest pattern wins.
+func (p *Pattern) MoreSpecific(other *Pattern) bool {
+	for i := 0; i < len(p.segments) && i < len(other.segments); i++ {
+		if p.segments[i].kind != other.segments[i].kind {
+			return p.segments[i].kind > other.segments[i].kind
+		}
+	}
+	if len(p.segments) != len(other.segments) {
+		return len(p.segments) > len(other.segments)
+	}
+	// same shape: sort by url to have a deterministic result
+	return p.raw < other.raw
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+	return strings.Split(path, "/")
+}
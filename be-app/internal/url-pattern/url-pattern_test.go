@@ -0,0 +1,100 @@
+package urlpatternpkg
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	for _, url := range []string{"url.com", "/users/{id}/orders", "users/{id:[0-9]+}", "files/*/content", "static/**", "/"} {
+		_, err := Compile(url)
+		assert.Nil(t, err, url)
+	}
+
+	_, err := Compile("users/{}")
+	assert.EqualError(t, err, "invalid segment '{}' in url 'users/{}': parameter name is empty")
+	_, err = Compile("users/id-{id}")
+	assert.EqualError(t, err, "invalid segment 'id-{id}' in url 'users/id-{id}': parameters must take the whole segment")
+	_, err = Compile("users/{id:[0-9}")
+	assert.ErrorContains(t, err, "invalid segment '{id:[0-9}'")
+	_, err = Compile("static/**/content")
+	assert.EqualError(t, err, "'**' must be the last segment of the url 'static/**/content'")
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		url    string
+		path   string
+		match  bool
+		params map[string]string
+	}{
+		{"url.com", "url.com", true, map[string]string{}},
+		{"/url.com/", "url.com", true, map[string]string{}},
+		{"url.com", "other.com", false, nil},
+		{"users/{id}/orders", "/users/42/orders", true, map[string]string{"id": "42"}},
+		{"users/{id}/orders", "users/42", false, nil},
+		{"users/{id}/orders", "users/42/orders/3", false, nil},
+		{"users/{id:[0-9]+}", "users/42", true, map[string]string{"id": "42"}},
+		{"users/{id:[0-9]+}", "users/john", false, nil},
+		{"files/*/content", "files/a.txt/content", true, map[string]string{}},
+		{"static/**", "static", true, map[string]string{}},
+		{"static/**", "static/css/main.css", true, map[string]string{}},
+		{"static/**", "assets/main.css", false, nil},
+	}
+	for _, test := range tests {
+		pattern, err := Compile(test.url)
+		assert.Nil(t, err)
+		params, match := pattern.Match(test.path)
+		assert.Equal(t, test.match, match, "%s - %s", test.url, test.path)
+		assert.Equal(t, test.params, params, "%s - %s", test.url, test.path)
+	}
+}
+
+func TestMoreSpecific(t *testing.T) {
+	urls := []string{"**", "users/**", "users/*", "users/{id}", "users/{id:[0-9]+}", "users/me", "users/{id}/orders"}
+	patterns := make([]*Pattern, 0)
+	for _, url := range urls {
+		pattern, err := Compile(url)
+		assert.Nil(t, err)
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool { return patterns[i].MoreSpecific(patterns[j]) })
+	sorted := make([]string, 0)
+	for _, pattern := range patterns {
+		sorted = append(sorted, pattern.String())
+	}
+	assert.Equal(t, []string{"users/me", "users/{id:[0-9]+}", "users/{id}/orders", "users/{id}", "users/*", "users/**", "**"}, sorted)
+}
+
+func TestTemplate(t *testing.T) {
+	pattern, err := Compile("/users/{id:[0-9]+}/*/orders/{orderId}/*/**")
+	assert.Nil(t, err)
+	template, params := pattern.Template()
+	assert.Equal(t, "users/{id}/{wildcard1}/orders/{orderId}/{wildcard2}/{path}", template)
+	assert.Equal(t, []Param{
+		{Name: "id", Regex: "[0-9]+"},
+		{Name: "wildcard1"},
+		{Name: "orderId"},
+		{Name: "wildcard2"},
+		{Name: "path", CatchAll: true},
+	}, params)
+
+	pattern, err = Compile("health")
+	assert.Nil(t, err)
+	template, params = pattern.Template()
+	assert.Equal(t, "health", template)
+	assert.Empty(t, params)
+}
+
+func TestKey(t *testing.T) {
+	// the patterns matching the same paths share the key
+	for _, url := range []string{"users/*/orders", "/users/{id}/orders/", "users/{userId}/orders"} {
+		assert.Equal(t, "users/*/orders", Key(url))
+	}
+	assert.Equal(t, "users/{:[0-9]+}/**", Key("users/{id:[0-9]+}/**"))
+	assert.NotEqual(t, Key("users/{id:[0-9]+}"), Key("users/{id:[a-z]+}"))
+	// invalid patterns
+	assert.Equal(t, "users/{id", Key("/users/{id/"))
+}
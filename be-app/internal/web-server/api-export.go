@@ -0,0 +1,45 @@
+package webserver
+
+import (
+	mockapipkg "dynamocker/internal/mock-api"
+	openapipkg "dynamocker/internal/openapi"
+	postmanpkg "dynamocker/internal/postman"
+	wiremockpkg "dynamocker/internal/wiremock"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// GET http://<dynamocker-server>/mock-apis/openapi
+// describe the mock apis as an OpenAPI 3 document, in json or, with format=yaml, in yaml
+func getMockApisOpenApi(w http.ResponseWriter, r *http.Request) {
+	doc := openapipkg.FromMockApis(mockapipkg.GetMockAPIs())
+	if r.URL.Query().Get("format") != "yaml" {
+		encodeJson(doc, w)
+		return
+	}
+	data, err := doc.Yaml()
+	if err != nil {
+		err := fmt.Errorf("error while encoding the OpenAPI document: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GET http://<dynamocker-server>/mock-apis/postman
+// describe the mock apis as a Postman collection (v2.1), whose baseUrl points to this server
+func getMockApisPostman(w http.ResponseWriter, r *http.Request) {
+	baseUrl := "http://" + r.Host + "/dynamocker/api/serve-mock-api"
+	encodeJson(postmanpkg.FromMockApis(mockapipkg.GetMockAPIs(), baseUrl), w)
+}
+
+// GET http://<dynamocker-server>/mock-apis/wiremock
+// describe the mock apis as WireMock stub mappings
+func getMockApisWireMock(w http.ResponseWriter, r *http.Request) {
+	encodeJson(wiremockpkg.FromMockApis(mockapipkg.GetMockAPIs()), w)
+}
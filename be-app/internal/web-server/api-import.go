@@ -0,0 +1,81 @@
+package webserver
+
+import (
+	harpkg "dynamocker/internal/har"
+	mockapifilepkg "dynamocker/internal/mock-api-file"
+	openapipkg "dynamocker/internal/openapi"
+	postmanpkg "dynamocker/internal/postman"
+	"fmt"
+	"io"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// POST http://<dynamocker-server>/import/openapi
+// create the mockApis described by the OpenAPI 3 document (yaml or json) in the body
+func importOpenApi(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		err := fmt.Errorf("error while reading request body: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
+		return
+	}
+	doc, err := openapipkg.Parse(body)
+	if err != nil {
+		err := fmt.Errorf("error while parsing the OpenAPI document: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusBadRequest)
+		return
+	}
+	mockApis, skipped := openapipkg.ToMockApis(doc)
+	encodeJson(mockapifilepkg.ImportMockApis(mockApis, skipped), w)
+}
+
+// POST http://<dynamocker-server>/import/har
+// create the mockApis from the entries of the HTTP Archive in the body. Supported
+// query parameters: keepHost, keepHeaders
+func importHar(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		err := fmt.Errorf("error while reading request body: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
+		return
+	}
+	har, err := harpkg.Parse(body)
+	if err != nil {
+		err := fmt.Errorf("error while parsing the har file: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusBadRequest)
+		return
+	}
+	opts := harpkg.Options{
+		KeepHost:    r.URL.Query().Get("keepHost") == "true",
+		KeepHeaders: r.URL.Query().Get("keepHeaders") == "true",
+	}
+	mockApis, skipped := harpkg.ToMockApis(har, opts)
+	encodeJson(mockapifilepkg.ImportMockApis(mockApis, skipped), w)
+}
+
+// POST http://<dynamocker-server>/import/postman
+// create the mockApis from the saved example responses of the Postman collection (v2.1) in the body
+func importPostman(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		err := fmt.Errorf("error while reading request body: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
+		return
+	}
+	collection, err := postmanpkg.Parse(body)
+	if err != nil {
+		err := fmt.Errorf("error while parsing the Postman collection: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusBadRequest)
+		return
+	}
+	mockApis, skipped := postmanpkg.ToMockApis(collection)
+	encodeJson(mockapifilepkg.ImportMockApis(mockApis, skipped), w)
+}
@@ -0,0 +1,100 @@
+package webserver
+
+import (
+	requestjournalpkg "dynamocker/internal/request-journal"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// GET http://<dynamocker-server>/requests
+// return the requests recorded in the journal. Supported query parameters:
+// method, url (regex), mockApiId, unmatched, status, since (RFC3339), limit
+func getRequests(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseJournalFilter(r)
+	if err != nil {
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusBadRequest)
+		return
+	}
+	encodeJson(requestjournalpkg.List(filter), w)
+}
+
+// DEL http://<dynamocker-server>/requests
+// clear the journal
+func deleteRequests(w http.ResponseWriter, r *http.Request) {
+	requestjournalpkg.Clear()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST http://<dynamocker-server>/requests/verify
+// count the recorded requests matching the pattern in the body and compare
+// the count with the expected one. Failed verifications list the closest
+// requests together with the reasons why they don't match
+func verifyRequests(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		err := fmt.Errorf("error while reading request body: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
+		return
+	}
+	var verification requestjournalpkg.Verification
+	if err := json.Unmarshal(body, &verification); err != nil {
+		err := fmt.Errorf("error while unmarshaling body: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusBadRequest)
+		return
+	}
+	result, err := requestjournalpkg.Verify(verification)
+	if err != nil {
+		err := fmt.Errorf("invalid verification: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusBadRequest)
+		return
+	}
+	encodeJson(result, w)
+}
+
+func parseJournalFilter(r *http.Request) (requestjournalpkg.Filter, error) {
+	var filter requestjournalpkg.Filter
+	query := r.URL.Query()
+	filter.Method = query.Get("method")
+	if urlRegex := query.Get("url"); urlRegex != "" {
+		regex, err := regexp.Compile(urlRegex)
+		if err != nil {
+			return filter, fmt.Errorf("invalid url regex: %s", err)
+		}
+		filter.URL = regex
+	}
+	filter.MockApiId = query.Get("mockApiId")
+	filter.Unmatched = query.Get("unmatched") == "true"
+	if status := query.Get("status"); status != "" {
+		code, err := strconv.Atoi(status)
+		if err != nil {
+			return filter, fmt.Errorf("invalid status '%s'", status)
+		}
+		filter.Status = code
+	}
+	if since := query.Get("since"); since != "" {
+		timestamp, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since timestamp, RFC3339 expected")
+		}
+		filter.Since = timestamp
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return filter, fmt.Errorf("invalid limit '%s'", limit)
+		}
+		filter.Limit = n
+	}
+	return filter, nil
+}
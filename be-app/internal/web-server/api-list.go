@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
@@ -30,7 +29,35 @@ var apis []Api = []Api{
 		},
 	},
 	{
-		resource: "mock-api/{uuid}",
+		resource: "mock-apis/openapi",
+		handler: map[Method]func(http.ResponseWriter, *http.Request){
+			GET:     getMockApisOpenApi,
+			OPTIONS: getOptions,
+		},
+	},
+	{
+		resource: "mock-apis/postman",
+		handler: map[Method]func(http.ResponseWriter, *http.Request){
+			GET:     getMockApisPostman,
+			OPTIONS: getOptions,
+		},
+	},
+	{
+		resource: "mock-apis/wiremock",
+		handler: map[Method]func(http.ResponseWriter, *http.Request){
+			GET:     getMockApisWireMock,
+			OPTIONS: getOptions,
+		},
+	},
+	{
+		resource: "mock-api/validate",
+		handler: map[Method]func(http.ResponseWriter, *http.Request){
+			POST:    validateMockApi,
+			OPTIONS: getOptions,
+		},
+	},
+	{
+		resource: "mock-api/{id}",
 		handler: map[Method]func(http.ResponseWriter, *http.Request){
 			GET:     getMockApi,
 			OPTIONS: getOptions,
@@ -39,13 +66,62 @@ var apis []Api = []Api{
 		},
 	},
 	{
-		resource: "serve-mock-api/{url}",
+		resource: "scenarios",
+		handler: map[Method]func(http.ResponseWriter, *http.Request){
+			GET:     getScenarios,
+			OPTIONS: getOptions,
+			DELETE:  deleteScenarios,
+		},
+	},
+	{
+		resource: "scenarios/{name}",
 		handler: map[Method]func(http.ResponseWriter, *http.Request){
-			GET:     serveMockApi,
+			GET:     getScenario,
 			OPTIONS: getOptions,
-			POST:    serveMockApi,
-			PATCH:   serveMockApi,
-			DELETE:  serveMockApi,
+			PUT:     putScenario,
+			DELETE:  deleteScenario,
+		},
+	},
+	{
+		resource: "requests/verify",
+		handler: map[Method]func(http.ResponseWriter, *http.Request){
+			POST:    verifyRequests,
+			OPTIONS: getOptions,
+		},
+	},
+	{
+		resource: "requests",
+		handler: map[Method]func(http.ResponseWriter, *http.Request){
+			GET:     getRequests,
+			OPTIONS: getOptions,
+			DELETE:  deleteRequests,
+		},
+	},
+	{
+		resource: "import/openapi",
+		handler: map[Method]func(http.ResponseWriter, *http.Request){
+			POST:    importOpenApi,
+			OPTIONS: getOptions,
+		},
+	},
+	{
+		resource: "import/har",
+		handler: map[Method]func(http.ResponseWriter, *http.Request){
+			POST:    importHar,
+			OPTIONS: getOptions,
+		},
+	},
+	{
+		resource: "import/postman",
+		handler: map[Method]func(http.ResponseWriter, *http.Request){
+			POST:    importPostman,
+			OPTIONS: getOptions,
+		},
+	},
+	{
+		resource: "serve-mock-api/{url:.*}",
+		handler: map[Method]func(http.ResponseWriter, *http.Request){
+			ANY: recordRequest(serveMockApi),
 		},
 	},
 }
@@ -62,8 +138,8 @@ func getOptions(w http.ResponseWriter, r *http.Request) {
 func getMockApis(w http.ResponseWriter, r *http.Request) {
 	mockApis := mockapipkg.GetMockApiList()
 	var resourceObjects []ResourceObject = make([]ResourceObject, 0)
-	for uuid, mockApi := range mockApis {
-		resourceObjects = append(resourceObjects, ResourceObject{ObjId: uuid, ObjType: MockApiType, ObtData: mockApi})
+	for id, mockApi := range mockApis {
+		resourceObjects = append(resourceObjects, ResourceObject{ObjId: id, ObjType: MockApiType, ObtData: mockApi})
 	}
 	encodeJson(resourceObjects, w)
 }
@@ -79,31 +155,23 @@ func deleteMockApis(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// GET http://<dynamocker-server>/mock-api/{uuid}
-// get mock api by uuid
+// GET http://<dynamocker-server>/mock-api/{id}
+// get mock api by id
 func getMockApi(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	mockApiUuidString, ok := vars["uuid"]
-	if mockApiUuidString == "" || !ok {
-		err := fmt.Errorf("no uuid provided")
+	mockApiId, ok := vars["id"]
+	if mockApiId == "" || !ok {
+		err := fmt.Errorf("no id provided")
 		log.Error(err)
 		encodeJsonError(err.Error(), w, http.StatusBadRequest)
 		return
 	}
-	mockApiUuid64, err := strconv.ParseUint(mockApiUuidString, 10, 16)
-	if err != nil {
-		err := fmt.Errorf("error while parsing uuid into uint16")
-		log.Error(err)
-		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
-		return
-	}
-	mockApiUuid := uint16(mockApiUuid64)
-	if mockApi, err := mockapipkg.GetMockAPI(mockApiUuid); err != nil {
+	if mockApi, err := mockapipkg.GetMockAPI(mockApiId); err != nil {
 		log.Error(err)
 		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
 		return
 	} else {
-		encodeJson(ResourceObject{ObjId: mockApiUuid, ObjType: MockApiType, ObtData: mockApi}, w)
+		encodeJson(ResourceObject{ObjId: mockApiId, ObjType: MockApiType, ObtData: mockApi}, w)
 	}
 }
 
@@ -129,26 +197,30 @@ func postMockApi(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// PUT http://<dynamocker-server>/mock-api/{uuid}
-// modify existing mock api
-func putMockApi(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	mockApiUuidString, ok := vars["uuid"]
-	if mockApiUuidString == "" || !ok {
-		err := fmt.Errorf("no uuid provided")
+// POST http://<dynamocker-server>/mock-api/validate
+// check the mock api without storing it, returning the list of the errors found
+func validateMockApi(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		err := fmt.Errorf("error while reading request body: %s", err)
 		log.Error(err)
-		encodeJsonError(err.Error(), w, http.StatusBadRequest)
+		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
 		return
 	}
+	encodeJson(mockapipkg.ValidateMockApi(body), w)
+}
 
-	mockApiUuid64, err := strconv.ParseUint(mockApiUuidString, 10, 16)
-	if err != nil {
-		err := fmt.Errorf("error while parsing uuid into uint16")
+// PUT http://<dynamocker-server>/mock-api/{id}
+// modify existing mock api
+func putMockApi(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	mockApiId, ok := vars["id"]
+	if mockApiId == "" || !ok {
+		err := fmt.Errorf("no id provided")
 		log.Error(err)
-		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
+		encodeJsonError(err.Error(), w, http.StatusBadRequest)
 		return
 	}
-	mockApiUuid := uint16(mockApiUuid64)
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -158,7 +230,7 @@ func putMockApi(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := mockapifilepkg.ModifyMockApiFile(mockApiUuid, body); err != nil {
+	if err := mockapifilepkg.ModifyMockApiFile(mockApiId, body); err != nil {
 		err := fmt.Errorf("error while modifying existing mock api: %s", err)
 		log.Error(err)
 		encodeJsonError(err.Error(), w, http.StatusBadRequest)
@@ -167,28 +239,19 @@ func putMockApi(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// DEL http://<dynamocker-server>/mock-api/{uuid}
+// DEL http://<dynamocker-server>/mock-api/{id}
 // delete mock api
 func deleteMockApi(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	mockApiUuidString, ok := vars["uuid"]
-	if mockApiUuidString == "" || !ok {
-		err := fmt.Errorf("no uuid provided")
+	mockApiId, ok := vars["id"]
+	if mockApiId == "" || !ok {
+		err := fmt.Errorf("no id provided")
 		log.Error(err)
 		encodeJsonError(err.Error(), w, http.StatusBadRequest)
 		return
 	}
 
-	mockApiUuid64, err := strconv.ParseUint(mockApiUuidString, 10, 16)
-	if err != nil {
-		err := fmt.Errorf("error while parsing uuid into uint16")
-		log.Error(err)
-		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
-		return
-	}
-	mockApiUuid := uint16(mockApiUuid64)
-
-	if err := mockapifilepkg.RemoveMockApiFile(mockApiUuid); err != nil {
+	if err := mockapifilepkg.RemoveMockApiFile(mockApiId); err != nil {
 		err := fmt.Errorf("error while removing the mocking api: %s", err)
 		log.Error(err)
 		encodeJsonError(err.Error(), w, http.StatusNotFound)
@@ -196,62 +259,3 @@ func deleteMockApi(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
-
-func serveMockApi(w http.ResponseWriter, r *http.Request) {
-	// retrieve the url
-	vars := mux.Vars(r)
-	mockApiUrl, ok := vars["url"]
-	if mockApiUrl == "" || !ok {
-		err := fmt.Errorf("no mockApiName provided")
-		log.Error(err)
-		encodeJsonError(err.Error(), w, http.StatusBadRequest)
-		return
-	}
-
-	// find the mockApi mathching the url
-	mockApi, found := mockapipkg.GetApiByUrl(mockApiUrl)
-	if !found {
-		err := fmt.Errorf("mockApi not found")
-		log.Error(err)
-		encodeJsonError(err.Error(), w, http.StatusNotFound)
-		return
-	}
-	switch r.Method {
-	case "GET":
-		if len(*mockApi.Responses.Get) == 0 {
-			err := fmt.Errorf("requested method not defined for this mockApi")
-			log.Error(err)
-			encodeJsonError(err.Error(), w, http.StatusNotFound)
-			return
-		}
-		encodeJson(mockApi.Responses.Get, w)
-		return
-	case "POST":
-		if len(*mockApi.Responses.Post) == 0 {
-			err := fmt.Errorf("requested method not defined for this mockApi")
-			log.Error(err)
-			encodeJsonError(err.Error(), w, http.StatusNotFound)
-			return
-		}
-		encodeJson(mockApi.Responses.Post, w)
-		return
-	case "PATCH":
-		if len(*mockApi.Responses.Patch) == 0 {
-			err := fmt.Errorf("requested method not defined for this mockApi")
-			log.Error(err)
-			encodeJsonError(err.Error(), w, http.StatusNotFound)
-			return
-		}
-		encodeJson(mockApi.Responses.Patch, w)
-		return
-	case "DELETE":
-		if len(*mockApi.Responses.Delete) == 0 {
-			err := fmt.Errorf("requested method not defined for this mockApi")
-			log.Error(err)
-			encodeJsonError(err.Error(), w, http.StatusNotFound)
-			return
-		}
-		encodeJson(mockApi.Responses.Delete, w)
-		return
-	}
-}
@@ -1,11 +1,9 @@
 package webserver
 
 import (
-	"dynamocker/internal/config"
+	"dynamocker/internal/common"
 	"encoding/json"
-	"io/fs"
 	"net/http"
-	"os"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -23,10 +21,29 @@ func encodeJson(data any, w http.ResponseWriter) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// write the status, the headers and the body defined by the mocked response.
+// The Content-Type depends on the body type, unless the response defines its own
+func encodeMockResponse(response *common.ResponseDef, w http.ResponseWriter) {
+	body, err := response.BodyBytes()
+	if err != nil {
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
+		return
+	}
+	if body != nil {
+		w.Header().Set("Content-Type", response.ContentType())
+	}
+	for key, value := range response.Headers {
+		w.Header().Set(key, value)
+	}
+	w.WriteHeader(response.StatusCode())
+	w.Write(body)
+}
+
 // encode the error in a JSON response and return the http status code
 // to the client
 func encodeJsonError(err string, w http.ResponseWriter, code int) {
-	if code > 500 || code < 0 {
+	if code > 599 || code < 0 {
 		code = http.StatusInternalServerError
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -38,39 +55,3 @@ func encodeJsonError(err string, w http.ResponseWriter, code int) {
 	}{ErrorMsg: errToClient[len(errToClient)-1]}
 	json.NewEncoder(w).Encode(jsonError)
 }
-
-func readJsonFilesFromFolder() []string {
-
-	array := []string{}
-
-	var files []fs.DirEntry
-
-	folderPath := config.GetMockApiFolder()
-
-	// get path from config package
-	if folderPath == "" {
-		log.Errorf("could not retrieve stored files: the mock API folder has not been set-up")
-	}
-
-	files, err := os.ReadDir(folderPath)
-
-	if err != nil {
-		log.Errorf("could not retrieve stored files: %s", err)
-	}
-
-	for _, file := range files {
-
-		// select only *.json files
-		if !strings.HasSuffix(file.Name(), ".json") {
-			continue
-		}
-
-		fileName, ok := strings.CutSuffix(file.Name(), ".json")
-		if !ok {
-			log.Errorf("could not retrieve stored files: could not CutSuffix")
-		}
-
-		array = append(array, fileName)
-	}
-	return array
-}
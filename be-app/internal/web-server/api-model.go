@@ -6,11 +6,13 @@ type Method string
 
 const (
 	GET              Method = http.MethodGet
+	HEAD             Method = http.MethodHead
 	POST             Method = http.MethodPost
 	PUT              Method = http.MethodPut
 	PATCH            Method = http.MethodPatch
 	DELETE           Method = http.MethodDelete
 	OPTIONS          Method = http.MethodOptions
+	ANY              Method = "" // any method, TRACE and the custom ones included
 	MockApiArrayType string = "arrayOfMockApis"
 	MockApiType      string = "mockApi"
 )
@@ -28,7 +30,12 @@ type Api struct {
 }
 
 type ResourceObject struct {
-	ObjId   uint16 `json:"id"`
+	ObjId   string `json:"id"`
 	ObjType string `json:"type"`
 	ObtData any    `json:"data"`
 }
+
+type ScenarioState struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
@@ -0,0 +1,87 @@
+package webserver
+
+import (
+	mockapipkg "dynamocker/internal/mock-api"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// GET http://<dynamocker-server>/scenarios
+// return the current state of all the scenarios
+func getScenarios(w http.ResponseWriter, r *http.Request) {
+	scenarios := make([]ScenarioState, 0)
+	for name, state := range mockapipkg.GetScenarioStates() {
+		scenarios = append(scenarios, ScenarioState{Name: name, State: state})
+	}
+	sort.Slice(scenarios, func(i, j int) bool { return scenarios[i].Name < scenarios[j].Name })
+	encodeJson(scenarios, w)
+}
+
+// DEL http://<dynamocker-server>/scenarios
+// reset all the scenarios and sequences
+func deleteScenarios(w http.ResponseWriter, r *http.Request) {
+	mockapipkg.ResetAllScenarios()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET http://<dynamocker-server>/scenarios/{name}
+// return the current state of the scenario
+func getScenario(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	state, found := mockapipkg.GetScenarioState(name)
+	if !found {
+		err := fmt.Errorf("scenario '%s' not found", name)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusNotFound)
+		return
+	}
+	encodeJson(ScenarioState{Name: name, State: state}, w)
+}
+
+// PUT http://<dynamocker-server>/scenarios/{name}
+// move the scenario to the state passed in the body
+func putScenario(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		err := fmt.Errorf("error while reading request body: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
+		return
+	}
+	var scenario ScenarioState
+	if err := json.Unmarshal(body, &scenario); err != nil {
+		err := fmt.Errorf("error while unmarshaling body: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusBadRequest)
+		return
+	}
+	if scenario.State == "" {
+		err := fmt.Errorf("no state provided")
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusBadRequest)
+		return
+	}
+	mockapipkg.SetScenarioState(name, scenario.State)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DEL http://<dynamocker-server>/scenarios/{name}
+// move the scenario back to the Started state and restart its sequences
+func deleteScenario(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if _, found := mockapipkg.GetScenarioState(name); !found {
+		err := fmt.Errorf("scenario '%s' not found", name)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusNotFound)
+		return
+	}
+	mockapipkg.ResetScenario(name)
+	w.WriteHeader(http.StatusNoContent)
+}
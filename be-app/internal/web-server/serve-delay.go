@@ -0,0 +1,51 @@
+package webserver
+
+import (
+	"dynamocker/internal/common"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// compute the duration of the delay. Random delays are sampled at each call
+func delayDuration(delay *common.Delay) time.Duration {
+	if delay == nil {
+		return 0
+	}
+	var milliseconds float64
+	switch delay.Type {
+	case common.DelayTypeFixed:
+		milliseconds = float64(delay.Milliseconds)
+	case common.DelayTypeUniform:
+		milliseconds = float64(delay.Min + rand.Intn(delay.Max-delay.Min+1))
+	case common.DelayTypeLognormal:
+		milliseconds = float64(delay.Median) * math.Exp(rand.NormFloat64()*delay.Sigma)
+	}
+	return time.Duration(milliseconds * float64(time.Millisecond))
+}
+
+// wait for the delay before serving the response. The write deadline of the
+// server is moved forward, so that the delayed response is not cut off. It
+// returns false if the client went away in the meantime.
+func applyDelay(delay time.Duration, w http.ResponseWriter, r *http.Request) bool {
+	if delay <= 0 {
+		return true
+	}
+	err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(delay + writeTimeout))
+	if err != nil {
+		log.Debugf("cannot extend the write deadline of the delayed response: %s", err)
+	}
+	log.Debugf("delaying the response of %s", delay)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		log.Infof("client closed the request while the response was delayed")
+		return false
+	}
+}
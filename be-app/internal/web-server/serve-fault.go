@@ -0,0 +1,117 @@
+package webserver
+
+import (
+	"bytes"
+	"dynamocker/internal/common"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// status codes used by the random_5xx fault
+var faultStatusCodes = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// pick the fault to be injected, if any. A single random number is compared
+// against the cumulative probabilities of the faults
+func pickFault(faults []common.Fault) *common.Fault {
+	if len(faults) == 0 {
+		return nil
+	}
+	roll := rand.Float64()
+	cumulative := 0.0
+	for i := range faults {
+		cumulative += faults[i].Probability
+		if roll < cumulative {
+			return &faults[i]
+		}
+	}
+	return nil
+}
+
+// serve the fault in place of the response
+func injectFault(fault *common.Fault, response *common.ResponseDef, w http.ResponseWriter) {
+	log.Infof("injecting fault '%s'", fault.Type)
+	switch fault.Type {
+	case common.FaultConnectionReset:
+		conn := hijackConnection(w)
+		if conn == nil {
+			return
+		}
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			// discard the unsent data and send RST instead of FIN
+			tcpConn.SetLinger(0)
+		}
+		conn.Close()
+	case common.FaultEmptyReply:
+		conn := hijackConnection(w)
+		if conn == nil {
+			return
+		}
+		conn.Close()
+	case common.FaultTruncatedBody:
+		body, err := response.BodyBytes()
+		if err != nil {
+			log.Error(err)
+		}
+		conn := hijackConnection(w)
+		if conn == nil {
+			return
+		}
+		defer conn.Close()
+		var raw bytes.Buffer
+		fmt.Fprintf(&raw, "HTTP/1.1 %d %s\r\n", response.StatusCode(), http.StatusText(response.StatusCode()))
+		fmt.Fprintf(&raw, "Content-Type: %s\r\n", response.ContentType())
+		for key, value := range response.Headers {
+			if http.CanonicalHeaderKey(key) != "Content-Type" {
+				fmt.Fprintf(&raw, "%s: %s\r\n", key, value)
+			}
+		}
+		fmt.Fprintf(&raw, "Content-Length: %d\r\n\r\n", len(body))
+		raw.Write(body[:len(body)/2])
+		conn.Write(raw.Bytes())
+	case common.FaultMalformedJson:
+		body, err := response.BodyBytes()
+		if err != nil {
+			log.Error(err)
+		}
+		for key, value := range response.Headers {
+			w.Header().Set(key, value)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(response.StatusCode())
+		w.Write(malformJson(body))
+	case common.FaultRandom5xx:
+		code := faultStatusCodes[rand.Intn(len(faultStatusCodes))]
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		fmt.Fprintf(w, "{\"error_msg\":\"%s\"}\n", http.StatusText(code))
+	}
+}
+
+// cut the json in half and append an invalid token, so that it can't be parsed
+func malformJson(body []byte) []byte {
+	body = bytes.TrimSpace(body)
+	malformed := append([]byte{}, body[:len(body)/2]...)
+	return append(malformed, []byte("<!malformed")...)
+}
+
+// take over the connection of the request. It returns nil if the connection
+// can't be hijacked
+func hijackConnection(w http.ResponseWriter) net.Conn {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		err := fmt.Errorf("cannot inject the fault, connection not hijackable: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
+		return nil
+	}
+	return conn
+}
@@ -0,0 +1,81 @@
+package webserver
+
+import (
+	"bytes"
+	requestjournalpkg "dynamocker/internal/request-journal"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// response writer keeping track of the status and of the matched mockApi,
+// to be recorded in the journal
+type journalWriter struct {
+	http.ResponseWriter
+	status      int
+	mockApiId   string
+	mockApiName string
+}
+
+func (jw *journalWriter) WriteHeader(code int) {
+	if jw.status == 0 {
+		jw.status = code
+	}
+	jw.ResponseWriter.WriteHeader(code)
+}
+
+func (jw *journalWriter) Write(b []byte) (int, error) {
+	if jw.status == 0 {
+		jw.status = http.StatusOK
+	}
+	return jw.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer, e.g.
+// for hijacking the connection or extending the write deadline
+func (jw *journalWriter) Unwrap() http.ResponseWriter {
+	return jw.ResponseWriter
+}
+
+// store the mockApi matched by the request, if the request is being recorded
+func setJournalMockApi(w http.ResponseWriter, id string, name string) {
+	if jw, ok := w.(*journalWriter); ok {
+		jw.mockApiId = id
+		jw.mockApiName = name
+	}
+}
+
+// record the requests served by the handler in the request journal
+func recordRequest(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		entry := requestjournalpkg.Entry{
+			Timestamp: start,
+			Method:    r.Method,
+			URL:       r.URL.String(),
+			Path:      strings.Trim(mux.Vars(r)["url"], "/"),
+			Headers:   r.Header.Clone(),
+		}
+		if r.Body != nil {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				log.Errorf("error while reading the body of the request to be recorded: %s", err)
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			entry.SetBody(body)
+		}
+
+		jw := &journalWriter{ResponseWriter: w}
+		next(jw, r)
+
+		entry.Status = jw.status
+		entry.MockApiId = jw.mockApiId
+		entry.MockApiName = jw.mockApiName
+		entry.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+		requestjournalpkg.Record(entry)
+	}
+}
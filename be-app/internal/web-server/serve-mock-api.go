@@ -0,0 +1,149 @@
+package webserver
+
+import (
+	"dynamocker/internal/common"
+	"dynamocker/internal/config"
+	mockapipkg "dynamocker/internal/mock-api"
+	requestmatcherpkg "dynamocker/internal/request-matcher"
+	responsetemplatepkg "dynamocker/internal/response-template"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// ANY http://<dynamocker-server>/serve-mock-api/{url}
+// serve the response of the mock api matching the url
+func serveMockApi(w http.ResponseWriter, r *http.Request) {
+	// retrieve the url
+	vars := mux.Vars(r)
+	mockApiUrl, ok := vars["url"]
+	if mockApiUrl == "" || !ok {
+		err := fmt.Errorf("no mockApiName provided")
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusBadRequest)
+		return
+	}
+
+	// find the mockApi mathching the url
+	id, mockApi, pathParams, found := mockapipkg.MatchApiByPath(mockApiUrl)
+	if !found {
+		if config.GetRecordUpstream() != "" {
+			proxyAndRecord(w, r, mockApiUrl, "", nil)
+			return
+		}
+		if passThrough(w, r, mockApiUrl) {
+			return
+		}
+		err := fmt.Errorf("mockApi not found")
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusNotFound)
+		return
+	}
+	setJournalMockApi(w, id, mockApi.Name)
+	log.Debugf("mockApi '%s' matched the url '%s' with parameters %v", mockApi.Name, mockApiUrl, pathParams)
+
+	methodResponse, found := mockApi.Responses.ByMethod()[r.Method]
+	if !found && r.Method == http.MethodHead {
+		// HEAD is served as GET, without body
+		if methodResponse, found = mockApi.Responses.ByMethod()[http.MethodGet]; found {
+			w = &headWriter{ResponseWriter: w}
+		}
+	}
+	if !found {
+		// the mock apis without custom OPTIONS answer the preflight requests
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Allow", allowedMethods(mockApi))
+			getOptions(w, r)
+			return
+		}
+		// while recording, the upstream response is added to the mockApi. The
+		// pass-through proxy serves only the urls matching no mockApi
+		if config.GetRecordUpstream() != "" {
+			proxyAndRecord(w, r, mockApiUrl, id, mockApi)
+			return
+		}
+		w.Header().Set("Allow", allowedMethods(mockApi))
+		err := fmt.Errorf("requested method not defined for this mockApi")
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := requestmatcherpkg.FromHttpRequest(r, mockApiUrl, pathParams)
+	if err != nil {
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
+		return
+	}
+
+	response := mockapipkg.SelectResponse(mockApi, methodResponse, req)
+	if response == nil {
+		err := fmt.Errorf("no response of the mockApi matches the request")
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusNotFound)
+		return
+	}
+	response, err = responsetemplatepkg.Render(response, req)
+	if err != nil {
+		err := fmt.Errorf("error while rendering the response template: %s", err)
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusInternalServerError)
+		return
+	}
+
+	// the delay of the response overrides the one of the mockApi
+	delay := response.Delay
+	if delay == nil {
+		delay = mockApi.Delay
+	}
+	if !applyDelay(delayDuration(delay), w, r) {
+		return
+	}
+	if fault := pickFault(mockApi.Faults); fault != nil {
+		injectFault(fault, response, w)
+		return
+	}
+	if _, head := w.(*headWriter); head {
+		// the Content-Length of the GET response is advertised
+		if body, err := response.BodyBytes(); err == nil && body != nil {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+	}
+	encodeMockResponse(response, w)
+}
+
+// methods served by the mockApi, for the Allow header
+func allowedMethods(mockApi *common.MockApi) string {
+	responses := mockApi.Responses.ByMethod()
+	methods := make([]string, 0, len(responses)+2)
+	for method := range responses {
+		methods = append(methods, method)
+	}
+	if _, found := responses[http.MethodGet]; found {
+		methods = append(methods, http.MethodHead)
+	}
+	if _, found := responses[http.MethodOptions]; !found {
+		methods = append(methods, http.MethodOptions)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+// response writer discarding the body, used to serve HEAD requests
+type headWriter struct {
+	http.ResponseWriter
+}
+
+func (hw *headWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer
+func (hw *headWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
@@ -0,0 +1,28 @@
+package webserver
+
+import (
+	proxypkg "dynamocker/internal/proxy"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// pass the request through to the upstream of the fallback proxy, if the
+// proxy is enabled for the path. It returns false if the request has not
+// been proxied. If the upstream can't be reached, the request is answered with
+// 502, telling it apart from the errors of dynamocker
+func passThrough(w http.ResponseWriter, r *http.Request, path string) bool {
+	fallback := proxypkg.GetFallback()
+	if !fallback.Proxies(path) {
+		return false
+	}
+	res, err := proxypkg.Forward(r, fallback.Upstream, path, fallback.Rewrite)
+	if err != nil {
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusBadGateway)
+		return true
+	}
+	log.Debugf("%s %s passed through to %s", r.Method, path, fallback.Upstream)
+	res.Write(w)
+	return true
+}
@@ -0,0 +1,103 @@
+package webserver
+
+import (
+	"dynamocker/internal/common"
+	"dynamocker/internal/config"
+	mockapipkg "dynamocker/internal/mock-api"
+	mockapifilepkg "dynamocker/internal/mock-api-file"
+	proxypkg "dynamocker/internal/proxy"
+	responsetemplatepkg "dynamocker/internal/response-template"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// time given to the watcher to load a recorded mockApi. Meanwhile the same
+// request is forwarded again, but not recorded twice
+const recordingWindow = 5 * time.Second
+
+// method and path of the requests recorded recently
+var recentRecordings sync.Map
+
+// lock of each recorded url: the responses of the same url are recorded one at
+// a time, so that they are all added to the same mockApi
+var recordingLocks sync.Map
+
+// forward the request to the recording upstream, return its response to the
+// client and save it as a mockApi. If a mockApi serving the path already
+// exists, the response is added to it as the response of the requested method
+func proxyAndRecord(w http.ResponseWriter, r *http.Request, path string, id string, mockApi *common.MockApi) {
+	// the response is recorded uncompressed, whatever the client accepts
+	res, err := proxypkg.Forward(r, config.GetRecordUpstream(), path, func(req *http.Request) {
+		req.Header.Del("Accept-Encoding")
+	})
+	if err != nil {
+		log.Error(err)
+		encodeJsonError(err.Error(), w, http.StatusBadGateway)
+		return
+	}
+	res.Write(w)
+
+	key := r.Method + " " + path
+	if recordedAt, found := recentRecordings.Load(key); found && time.Since(recordedAt.(time.Time)) < recordingWindow {
+		return
+	}
+	recentRecordings.Store(key, time.Now())
+
+	if err := recordResponse(r.Method, path, res, id, mockApi); err != nil {
+		log.Errorf("response of %s not recorded: %s", key, err)
+		recentRecordings.Delete(key)
+		return
+	}
+	log.Infof("recorded the upstream response of %s", key)
+}
+
+func recordResponse(method string, path string, res *proxypkg.Response, id string, mockApi *common.MockApi) error {
+	recorded, err := proxypkg.RecordedMockApi(method, path, res)
+	if err != nil {
+		return err
+	}
+
+	lock, _ := recordingLocks.LoadOrStore(recorded.URL, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	// the matched mockApi may miss the responses recorded meanwhile
+	if loadedId, loaded, found := mockapipkg.FindApiByUrl(recorded.URL); found {
+		id, mockApi = loadedId, loaded
+	}
+	if mockApi == nil {
+		recorded.ID = responsetemplatepkg.NewUuid()
+		body, err := json.Marshal(recorded)
+		if err != nil {
+			return err
+		}
+		if err := mockapifilepkg.AddNewMockApiFile(body); err != nil {
+			return err
+		}
+		mockapipkg.PutMockApi(recorded.ID, recorded)
+		return nil
+	}
+
+	// the response is added to the existing mockApi only if it serves exactly
+	// this path: a pattern may serve other paths too
+	if mockApi.URL != recorded.URL && strings.Trim(mockApi.URL, "/") != recorded.URL {
+		return fmt.Errorf("the url of the mockApi '%s' is a pattern", mockApi.Name)
+	}
+	modified := *mockApi
+	modified.Responses.SetByMethod(method, recorded.Responses.ByMethod()[method])
+	body, err := json.Marshal(modified)
+	if err != nil {
+		return err
+	}
+	if err := mockapifilepkg.ModifyMockApiFile(id, body); err != nil {
+		return err
+	}
+	mockapipkg.PutMockApi(id, &modified)
+	return nil
+}
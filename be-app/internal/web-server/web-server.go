@@ -11,6 +11,10 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// maximum duration for writing a response. Delayed mock responses extend the
+// deadline by their delay
+var writeTimeout = 10 * time.Second
+
 type WebServer struct {
 	router  *mux.Router
 	webPort string
@@ -49,7 +53,7 @@ func (ws WebServer) Start(closeCh chan bool, wg *sync.WaitGroup) {
 	srv := &http.Server{
 		Addr:         "0.0.0.0:" + ws.webPort,
 		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		WriteTimeout: writeTimeout,
 		IdleTimeout:  20 * time.Second,
 		Handler:      ws.router,
 	}
@@ -71,7 +75,10 @@ func (ws WebServer) Start(closeCh chan bool, wg *sync.WaitGroup) {
 func (ws WebServer) registerApis() error {
 	for _, api := range ws.apiList {
 		for method, handler := range api.handler {
-			ws.router.HandleFunc("/dynamocker/api/"+api.resource, handler).Methods(string(method))
+			route := ws.router.HandleFunc("/dynamocker/api/"+api.resource, handler)
+			if method != ANY {
+				route.Methods(string(method))
+			}
 		}
 	}
 	return nil
@@ -90,7 +97,7 @@ func loggingMiddleware(next http.Handler) http.Handler {
 func headersMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "OPTIONS,GET,HEAD,POST,PUT,DELETE")
+		w.Header().Set("Access-Control-Allow-Methods", "OPTIONS,GET,HEAD,POST,PUT,PATCH,DELETE")
 		w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Accept")
 		next.ServeHTTP(w, r)
 	})
@@ -2,8 +2,15 @@ package webserver
 
 import (
 	"bytes"
+	"compress/gzip"
 	"dynamocker/internal/common"
 	mockapipkg "dynamocker/internal/mock-api"
+	mockapifilepkg "dynamocker/internal/mock-api-file"
+	openapipkg "dynamocker/internal/openapi"
+	postmanpkg "dynamocker/internal/postman"
+	proxypkg "dynamocker/internal/proxy"
+	requestjournalpkg "dynamocker/internal/request-journal"
+	wiremockpkg "dynamocker/internal/wiremock"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,7 +19,6 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
-	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -71,10 +77,10 @@ func dummyMockApi(t *testing.T) common.MockApi {
 
 // write a dummy mock api file to the Temp folder. The temp folder
 // comes from os package
-func writeDummyMockApiFile(t *testing.T) (uint16, *os.File, common.MockApi) {
+func writeDummyMockApiFile(t *testing.T) (string, *os.File, common.MockApi) {
 	mockApi := dummyMockApi(t)
-	uuid := uint16(rand.Intn(1000))
-	filename := fmt.Sprintf("%d", uuid) + ".json"
+	id := fmt.Sprintf("dummy-mock-api-%d", rand.Intn(1000))
+	filename := id + ".json"
 	filePath := os.TempDir() + "/" + filename
 	file, err := os.Create(filePath)
 	if err != nil {
@@ -87,7 +93,8 @@ func writeDummyMockApiFile(t *testing.T) (uint16, *os.File, common.MockApi) {
 		file.Close()
 		t.Fatal("malformed string modification")
 	}
-	mockApi.Name = fmt.Sprintf("dummy-mock-api-%d", uuid)
+	mockApi.ID = id
+	mockApi.Name = id
 	data, err := json.Marshal(mockApi)
 	if err != nil {
 		file.Close()
@@ -97,7 +104,7 @@ func writeDummyMockApiFile(t *testing.T) (uint16, *os.File, common.MockApi) {
 		file.Close()
 		t.Fatalf("error while writing dummy mock api to file :%s", err)
 	}
-	return uuid, file, mockApi
+	return id, file, mockApi
 }
 
 func TestGetMockApi(t *testing.T) {
@@ -106,24 +113,24 @@ func TestGetMockApi(t *testing.T) {
 	closeCh, webServerTest := setup(t)
 
 	// write dummy mock Api
-	uuid, _, mockApi := writeDummyMockApiFile(t)
+	id, _, mockApi := writeDummyMockApiFile(t)
 	defer func() {
 		closeCh <- true
 		// wait
 		time.Sleep(50 * time.Millisecond)
-		removeMockApiFile(t, uuid)
+		removeMockApiFile(t, id)
 	}()
 
 	// wait
 	time.Sleep(50 * time.Millisecond)
 
-	// test get/{uuid} api
+	// test get/{id} api
 	r := httptest.NewRecorder()
-	url := "/dynamocker/api/mock-api/" + fmt.Sprint(uuid)
+	url := "/dynamocker/api/mock-api/" + id
 	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", url, nil))
 	assert.Equal(t, http.StatusOK, r.Code)
 	resObj := ResourceObject{
-		ObjId:   uuid,
+		ObjId:   id,
 		ObjType: MockApiType,
 		ObtData: mockApi,
 	}
@@ -147,18 +154,18 @@ func TestGetMockApis(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 
 	// write three mock apis
-	uuid1, _, _ := writeDummyMockApiFile(t)
+	id1, _, _ := writeDummyMockApiFile(t)
 	time.Sleep(50 * time.Millisecond)
-	uuid2, _, _ := writeDummyMockApiFile(t)
+	id2, _, _ := writeDummyMockApiFile(t)
 	time.Sleep(50 * time.Millisecond)
-	uuid3, _, _ := writeDummyMockApiFile(t)
+	id3, _, _ := writeDummyMockApiFile(t)
 
 	defer func() {
 		// wait
 		time.Sleep(50 * time.Millisecond)
-		removeMockApiFile(t, uuid1)
-		removeMockApiFile(t, uuid2)
-		removeMockApiFile(t, uuid3)
+		removeMockApiFile(t, id1)
+		removeMockApiFile(t, id2)
+		removeMockApiFile(t, id3)
 	}()
 
 	// wait
@@ -191,16 +198,16 @@ func TestDeleteMockApis(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 
 	// write three mock apis
-	uuid1, _, _ := writeDummyMockApiFile(t)
-	uuid2, _, _ := writeDummyMockApiFile(t)
-	uuid3, _, _ := writeDummyMockApiFile(t)
+	id1, _, _ := writeDummyMockApiFile(t)
+	id2, _, _ := writeDummyMockApiFile(t)
+	id3, _, _ := writeDummyMockApiFile(t)
 
 	defer func() {
 		// wait
 		time.Sleep(50 * time.Millisecond)
-		removeMockApiFile(t, uuid1)
-		removeMockApiFile(t, uuid2)
-		removeMockApiFile(t, uuid3)
+		removeMockApiFile(t, id1)
+		removeMockApiFile(t, id2)
+		removeMockApiFile(t, id3)
 	}()
 
 	// wait
@@ -228,23 +235,23 @@ func TestDeleteMockApi(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 
 	// write three mock apis
-	uuid1, _, _ := writeDummyMockApiFile(t)
-	uuid2, _, _ := writeDummyMockApiFile(t)
-	uuid3, _, _ := writeDummyMockApiFile(t)
+	id1, _, _ := writeDummyMockApiFile(t)
+	id2, _, _ := writeDummyMockApiFile(t)
+	id3, _, _ := writeDummyMockApiFile(t)
 
 	defer func() {
 		// wait
 		time.Sleep(50 * time.Millisecond)
-		removeMockApiFile(t, uuid1)
-		removeMockApiFile(t, uuid2)
-		removeMockApiFile(t, uuid3)
+		removeMockApiFile(t, id1)
+		removeMockApiFile(t, id2)
+		removeMockApiFile(t, id3)
 	}()
 
 	// wait
 	time.Sleep(50 * time.Millisecond)
 
 	// test delete api
-	url := "/dynamocker/api/mock-api/" + fmt.Sprint(uuid1)
+	url := "/dynamocker/api/mock-api/" + id1
 	r := httptest.NewRecorder()
 	webServerTest.router.ServeHTTP(r, httptest.NewRequest("DELETE", url, nil))
 	assert.Equal(t, http.StatusNoContent, r.Code)
@@ -286,9 +293,9 @@ func TestPostMockApi(t *testing.T) {
 	// check content of file
 	var files []fs.DirEntry
 	var jsonFilescounter = 0
-	var uuidString string
+	var fileName string
 	var found = false
-	// retrieve uuid of the mockApi just written in the temp file after the POST request
+	// retrieve the file of the mockApi just written in the temp folder after the POST request
 	if files, err = os.ReadDir(os.TempDir() + "/"); err != nil {
 		t.Fatalf("error while getting entries from the mock api folder: %s", err)
 	}
@@ -298,25 +305,20 @@ func TestPostMockApi(t *testing.T) {
 			continue
 		}
 		jsonFilescounter++
-		uuidString, found = strings.CutSuffix(file.Name(), ".json")
+		fileName, found = file.Name(), true
 	}
 	assert.True(t, found)
 	assert.Equal(t, 1, jsonFilescounter, "this means that some other json file is present in the test folder, jeopardizing the test result")
 
-	mockApiUuid64, err := strconv.ParseUint(uuidString, 10, 16)
-	if err != nil {
-		err := fmt.Errorf("error while parsing uuid '%s' into uint16", uuidString)
-		t.Fatal(err)
-	}
-	uuid := uint16(mockApiUuid64)
-
 	defer func() {
 		// wait
 		time.Sleep(50 * time.Millisecond)
-		removeMockApiFile(t, uuid)
+		os.Remove(os.TempDir() + "/" + fileName)
 	}()
 
-	jsonFile, err := os.Open(os.TempDir() + "/" + uuidString + ".json")
+	// the file is named after the mockApi
+	assert.Equal(t, mockApiPost.Name+".json", fileName)
+	jsonFile, err := os.Open(os.TempDir() + "/" + fileName)
 	if err != nil {
 		t.Fatalf("cannot open the file: %s", err)
 	}
@@ -331,6 +333,7 @@ func TestPostMockApi(t *testing.T) {
 	}
 
 	// check that the mockApi passed to the POST is equal to the one just read from the file
+	assert.NotEmpty(t, mockApi.ID)
 	assert.Equal(t, mockApiPost.URL, mockApi.URL)
 	assert.Equal(t, mockApiPost.Name, mockApi.Name)
 	assert.Equal(t, mockApiPost.Responses.Get, mockApi.Responses.Get)
@@ -348,12 +351,12 @@ func TestPutMockApi(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 
 	// write mock api
-	uuid, _, mockApi := writeDummyMockApiFile(t)
+	id, _, mockApi := writeDummyMockApiFile(t)
 
 	defer func() {
 		// wait
 		time.Sleep(50 * time.Millisecond)
-		removeMockApiFile(t, uuid)
+		removeMockApiFile(t, id)
 	}()
 
 	// wait
@@ -361,7 +364,7 @@ func TestPutMockApi(t *testing.T) {
 
 	assert.Equal(t, 1, len(mockapipkg.GetMockApiList()))
 
-	url := "/dynamocker/api/mock-api/" + fmt.Sprint(uuid)
+	url := "/dynamocker/api/mock-api/" + id
 	r := httptest.NewRecorder()
 
 	// test patch api
@@ -401,47 +404,1235 @@ func TestPutMockApi(t *testing.T) {
 	assert.Equal(t, currentMockApi.Responses.Patch, mockApi.Responses.Patch)
 }
 
+func TestMemoryStore(t *testing.T) {
+	// mock apis kept in memory: nothing is written in the folder
+	t.Setenv("DYNA_STORE", "memory")
+	closeCh, webServerTest := setup(t)
+	defer func() { closeCh <- true }()
+	filesBefore, _ := os.ReadDir(os.TempDir())
+
+	mockApi := dummyMockApi(t)
+	mockApi.Name = "qa-memory"
+	mockApi.URL = "qa-memory"
+	body, _ := json.Marshal(mockApi)
+	r := httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", "/dynamocker/api/mock-api", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+
+	// served straight away, without waiting for the folder watcher
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/serve-mock-api/qa-memory", nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+
+	filesAfter, _ := os.ReadDir(os.TempDir())
+	assert.Equal(t, len(filesBefore), len(filesAfter))
+
+	var id string
+	for mockApiId, loaded := range mockapipkg.GetMockApiList() {
+		if loaded.Name == "qa-memory" {
+			id = mockApiId
+		}
+	}
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("DELETE", "/dynamocker/api/mock-api/"+id, nil))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/serve-mock-api/qa-memory", nil))
+	assert.Equal(t, http.StatusNotFound, r.Code)
+}
+
+func TestValidateMockApi(t *testing.T) {
+	t.Setenv("DYNA_STORE", "memory")
+	closeCh, webServerTest := setup(t)
+	defer close(closeCh)
+
+	validate := func(body string) []mockapifilepkg.ValidationError {
+		r := httptest.NewRecorder()
+		webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", "/dynamocker/api/mock-api/validate", strings.NewReader(body)))
+		assert.Equal(t, http.StatusOK, r.Code)
+		var errs []mockapifilepkg.ValidationError
+		if err := json.NewDecoder(r.Body).Decode(&errs); err != nil {
+			t.Fatalf("error while decoding the validation errors: %s", err)
+		}
+		return errs
+	}
+
+	// valid mock api, nothing is stored
+	mockApi := `{"id":"qa-validate","name":"qa-validate","url":"qa-validate","responses":{"get":{"status":204}}}`
+	assert.Empty(t, validate(mockApi))
+	assert.Empty(t, mockapipkg.GetMockApiList())
+
+	// the fields of the errors are the json ones
+	assert.Equal(t, []mockapifilepkg.ValidationError{
+		{Field: "url", Rule: "required", Message: "'url' is required"},
+		{Field: "responses.get.status", Rule: "min", Message: "'responses.get.status' must be at least 100"},
+	}, validate(`{"name":"qa-invalid","responses":{"get":{"status":42}}}`))
+
+	// the name and the url must not be used by another mock api, unless it is
+	// the one being modified
+	r := httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", "/dynamocker/api/mock-api", strings.NewReader(mockApi)))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+	assert.Empty(t, validate(mockApi))
+	assert.Equal(t, []mockapifilepkg.ValidationError{
+		{Field: "name", Rule: "unique", Message: "found another mockApi with the same name 'qa-validate'"},
+		{Field: "url", Rule: "unique", Message: "found another mockApi with the same URL 'qa-validate'"},
+	}, validate(`{"name":"qa-validate","url":"qa-validate","responses":{"get":{"status":204}}}`))
+
+	// invalid json
+	assert.Equal(t, []mockapifilepkg.ValidationError{{Rule: "json", Message: "unexpected end of JSON input"}}, validate(`{"name":`))
+}
+
 func TestServeMockApi(t *testing.T) {
-	assert.True(t, true)
 	// setup server and mockApi mgmt
-	// closeCh, webServerTest := setup(t)
-	// defer func() { closeCh <- true }()
+	closeCh, webServerTest := setup(t)
+	defer func() { closeCh <- true }()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	// write mock api
+	id, _, mockApi := writeDummyMockApiFile(t)
+	defer func() {
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFile(t, id)
+	}()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, 1, len(mockapipkg.GetMockApiList()))
+
+	// legacy body is served with 200
+	r := httptest.NewRecorder()
+	url := "/dynamocker/api/serve-mock-api/" + mockApi.URL
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", url, nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/json", r.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"valid_json":true,"body":"this is the response"}`, r.Body.String())
+
+	// modify the mock api using status codes and headers
+	if json.Unmarshal([]byte(`{"status":201,"headers":{"Location":"/items/3"},"body":{"id":3}}`), &mockApi.Responses.Post) != nil {
+		t.Fatalf("error while unmarshalling")
+	}
+	if json.Unmarshal([]byte(`{"status":503,"headers":{"Retry-After":"120"}}`), &mockApi.Responses.Delete) != nil {
+		t.Fatalf("error while unmarshalling")
+	}
+	mockApi.Responses.Patch = &common.MethodResponse{}
+	bytesPut, err := json.Marshal(mockApi)
+	if err != nil {
+		t.Fatalf("error while marshalign object : %s", err)
+	}
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("PUT", "/dynamocker/api/mock-api/"+id, bytes.NewBuffer(bytesPut)))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", url, nil))
+	assert.Equal(t, http.StatusCreated, r.Code)
+	assert.Equal(t, "/items/3", r.Header().Get("Location"))
+	assert.JSONEq(t, `{"id":3}`, r.Body.String())
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("DELETE", url, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, r.Code)
+	assert.Equal(t, "120", r.Header().Get("Retry-After"))
+	assert.Empty(t, r.Body.String())
+
+	// empty response means that the method is not defined
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("PATCH", url, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, r.Code)
+	assert.Equal(t, "DELETE, GET, HEAD, OPTIONS, POST", r.Header().Get("Allow"))
+}
+
+func TestServeMockApiMethods(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	defer func() { closeCh <- true }()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	// write mock api
+	id, _, mockApi := writeDummyMockApiFile(t)
+	defer func() {
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFile(t, id)
+	}()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	url := "/dynamocker/api/serve-mock-api/" + mockApi.URL
+
+	// HEAD is derived from GET
+	r := httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("HEAD", url, nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/json", r.Header().Get("Content-Type"))
+	assert.Equal(t, "50", r.Header().Get("Content-Length"))
+	assert.Empty(t, r.Body.String())
+
+	// OPTIONS not defined: the allowed methods are listed
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("OPTIONS", url, nil))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+	assert.Equal(t, "DELETE, GET, HEAD, OPTIONS, PATCH, POST", r.Header().Get("Allow"))
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("PUT", url, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, r.Code)
+	assert.Equal(t, "DELETE, GET, HEAD, OPTIONS, PATCH, POST", r.Header().Get("Allow"))
+
+	// the methods no mockApi can define are not allowed either
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("TRACE", url, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, r.Code)
+	assert.Equal(t, "DELETE, GET, HEAD, OPTIONS, PATCH, POST", r.Header().Get("Allow"))
+
+	// define PUT and OPTIONS
+	if json.Unmarshal([]byte(`{"status":200,"body":{"updated":true}}`), &mockApi.Responses.Put) != nil {
+		t.Fatalf("error while unmarshalling")
+	}
+	if json.Unmarshal([]byte(`{"status":200,"headers":{"Allow":"GET, PUT"}}`), &mockApi.Responses.Options) != nil {
+		t.Fatalf("error while unmarshalling")
+	}
+	mockApi.Responses.Get = nil
+	bytesPut, err := json.Marshal(mockApi)
+	if err != nil {
+		t.Fatalf("error while marshalign object : %s", err)
+	}
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("PUT", "/dynamocker/api/mock-api/"+id, bytes.NewBuffer(bytesPut)))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("PUT", url, nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.JSONEq(t, `{"updated":true}`, r.Body.String())
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("OPTIONS", url, nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "GET, PUT", r.Header().Get("Allow"))
+
+	// without GET, HEAD is not allowed
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("HEAD", url, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, r.Code)
+	assert.Equal(t, "DELETE, OPTIONS, PATCH, POST, PUT", r.Header().Get("Allow"))
+}
+
+func TestServeMockApiBodyTypes(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	defer func() { closeCh <- true }()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	// write mock api
+	id, _, mockApi := writeDummyMockApiFile(t)
+	defer func() {
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFile(t, id)
+	}()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	if json.Unmarshal([]byte(`{"body":[{"id":1},{"id":2}]}`), &mockApi.Responses.Get) != nil {
+		t.Fatalf("error while unmarshalling")
+	}
+	if json.Unmarshal([]byte(`{"bodyType":"text","headers":{"Content-Type":"text/csv"},"body":"id,name\n1,john\n"}`), &mockApi.Responses.Post) != nil {
+		t.Fatalf("error while unmarshalling")
+	}
+	if json.Unmarshal([]byte(`{"bodyType":"base64","headers":{"Content-Type":"application/pdf"},"body":"JVBERi0xLjQK"}`), &mockApi.Responses.Patch) != nil {
+		t.Fatalf("error while unmarshalling")
+	}
+	if json.Unmarshal([]byte(`{"bodyType":"text","body":"<html></html>"}`), &mockApi.Responses.Delete) != nil {
+		t.Fatalf("error while unmarshalling")
+	}
+	bytesPut, err := json.Marshal(mockApi)
+	if err != nil {
+		t.Fatalf("error while marshalign object : %s", err)
+	}
+	r := httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("PUT", "/dynamocker/api/mock-api/"+id, bytes.NewBuffer(bytesPut)))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	url := "/dynamocker/api/serve-mock-api/" + mockApi.URL
+
+	// json array
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", url, nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/json", r.Header().Get("Content-Type"))
+	assert.JSONEq(t, `[{"id":1},{"id":2}]`, r.Body.String())
+
+	// raw text with custom content type
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", url, nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "text/csv", r.Header().Get("Content-Type"))
+	assert.Equal(t, "id,name\n1,john\n", r.Body.String())
+
+	// binary body
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("PATCH", url, nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/pdf", r.Header().Get("Content-Type"))
+	assert.Equal(t, []byte("%PDF-1.4\n"), r.Body.Bytes())
+
+	// raw text with default content type
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("DELETE", url, nil))
+	assert.Equal(t, "text/plain; charset=utf-8", r.Header().Get("Content-Type"))
+	assert.Equal(t, "<html></html>", r.Body.String())
+}
+
+func TestServeMockApiCandidates(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	defer func() { closeCh <- true }()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	// write mock api
+	id, _, mockApi := writeDummyMockApiFile(t)
+	defer func() {
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFile(t, id)
+	}()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	mockApi.URL = "users/{id}/items"
+	if json.Unmarshal([]byte(`{"body":{"items":"all"},"candidates":[
+		{"match":{"query":{"status":{"equalTo":"active"}}},"body":{"items":"active"}},
+		{"match":{"query":{"status":{"equalTo":"archived"}},"pathParams":{"id":{"equalTo":"42"}}},"body":{"items":"archived"}}
+	]}`), &mockApi.Responses.Get) != nil {
+		t.Fatalf("error while unmarshalling")
+	}
+	if json.Unmarshal([]byte(`{"candidates":[
+		{"match":{"body":[{"jsonPath":"$.user","equalTo":"john"},{"jsonPath":"$.password","equalTo":"secret"}]},"body":{"token":"abc"}},
+		{"match":{"headers":{"X-Admin":{}}},"status":403}
+	]}`), &mockApi.Responses.Post) != nil {
+		t.Fatalf("error while unmarshalling")
+	}
+	bytesPut, err := json.Marshal(mockApi)
+	if err != nil {
+		t.Fatalf("error while marshalign object : %s", err)
+	}
+	r := httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("PUT", "/dynamocker/api/mock-api/"+id, bytes.NewBuffer(bytesPut)))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	baseUrl := "/dynamocker/api/serve-mock-api/users/"
+	tests := []struct {
+		method string
+		url    string
+		body   string
+		status int
+		resp   string
+	}{
+		{"GET", baseUrl + "42/items?status=active", "", http.StatusOK, `{"items":"active"}`},
+		{"GET", baseUrl + "42/items?status=archived", "", http.StatusOK, `{"items":"archived"}`},
+		{"GET", baseUrl + "43/items?status=archived", "", http.StatusOK, `{"items":"all"}`},
+		{"GET", baseUrl + "42/items", "", http.StatusOK, `{"items":"all"}`},
+		{"POST", baseUrl + "42/items", `{"user":"john","password":"secret"}`, http.StatusOK, `{"token":"abc"}`},
+		{"POST", baseUrl + "42/items", `{"user":"john","password":"wrong"}`, http.StatusNotFound, `{"error_msg":"no response of the mockApi matches the request"}`},
+	}
+	for _, test := range tests {
+		r = httptest.NewRecorder()
+		webServerTest.router.ServeHTTP(r, httptest.NewRequest(test.method, test.url, strings.NewReader(test.body)))
+		assert.Equal(t, test.status, r.Code, test.url)
+		assert.JSONEq(t, test.resp, r.Body.String(), test.url)
+	}
+
+	// candidate without body
+	r = httptest.NewRecorder()
+	req := httptest.NewRequest("POST", baseUrl+"1/items", nil)
+	req.Header.Set("X-Admin", "true")
+	webServerTest.router.ServeHTTP(r, req)
+	assert.Equal(t, http.StatusForbidden, r.Code)
+}
+
+func TestDelayDuration(t *testing.T) {
+	assert.Equal(t, time.Duration(0), delayDuration(nil))
+	assert.Equal(t, 150*time.Millisecond, delayDuration(&common.Delay{Type: common.DelayTypeFixed, Milliseconds: 150}))
+	for i := 0; i < 100; i++ {
+		delay := delayDuration(&common.Delay{Type: common.DelayTypeUniform, Min: 10, Max: 20})
+		assert.GreaterOrEqual(t, delay, 10*time.Millisecond)
+		assert.LessOrEqual(t, delay, 20*time.Millisecond)
+
+		delay = delayDuration(&common.Delay{Type: common.DelayTypeLognormal, Median: 100, Sigma: 0.5})
+		assert.Greater(t, delay, time.Duration(0))
+	}
+	// without sigma, the lognormal delay is always equal to the median
+	assert.Equal(t, 100*time.Millisecond, delayDuration(&common.Delay{Type: common.DelayTypeLognormal, Median: 100}))
+}
+
+func TestApplyDelayExtendsWriteTimeout(t *testing.T) {
+	defaultWriteTimeout := writeTimeout
+	writeTimeout = 100 * time.Millisecond
+	defer func() { writeTimeout = defaultWriteTimeout }()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if applyDelay(300*time.Millisecond, w, r) {
+			w.Write([]byte("delayed"))
+		}
+	}))
+	srv.Config.WriteTimeout = writeTimeout
+	srv.Start()
+	defer srv.Close()
+
+	start := time.Now()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("the delayed response has been cut off: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "delayed", string(body))
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+}
+
+func TestServeMockApiDelay(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	defer func() { closeCh <- true }()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	// write mock api
+	id, _, mockApi := writeDummyMockApiFile(t)
+	defer func() {
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFile(t, id)
+	}()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	// the mockApi delay applies to all methods, unless overridden
+	mockApi.Delay = &common.Delay{Type: common.DelayTypeFixed, Milliseconds: 200}
+	if json.Unmarshal([]byte(`{"delay":{"type":"fixed"},"body":{"fast":true}}`), &mockApi.Responses.Post) != nil {
+		t.Fatalf("error while unmarshalling")
+	}
+	bytesPut, err := json.Marshal(mockApi)
+	if err != nil {
+		t.Fatalf("error while marshalign object : %s", err)
+	}
+	r := httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("PUT", "/dynamocker/api/mock-api/"+id, bytes.NewBuffer(bytesPut)))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	url := "/dynamocker/api/serve-mock-api/" + mockApi.URL
+	start := time.Now()
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", url, nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	start = time.Now()
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", url, nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestPickFault(t *testing.T) {
+	assert.Nil(t, pickFault(nil))
+	assert.Nil(t, pickFault([]common.Fault{{Type: common.FaultEmptyReply, Probability: 0}}))
 
-	// // wait
-	// time.Sleep(50 * time.Millisecond)
+	faults := []common.Fault{
+		{Type: common.FaultEmptyReply, Probability: 0},
+		{Type: common.FaultRandom5xx, Probability: 1},
+	}
+	assert.Equal(t, &faults[1], pickFault(faults))
 
-	// // write mock api
-	// uuid, _, mockApi := writeDummyMockApiFile(t)
+	injected := 0
+	for i := 0; i < 1000; i++ {
+		if pickFault([]common.Fault{{Type: common.FaultEmptyReply, Probability: 0.3}}) != nil {
+			injected++
+		}
+	}
+	assert.InDelta(t, 300, injected, 100)
+}
 
-	// defer func() {
-	// 	// wait
-	// 	time.Sleep(50 * time.Millisecond)
-	// 	removeMockApiFile(t, uuid)
-	// }()
+func TestInjectFault(t *testing.T) {
+	response := &common.ResponseDef{Status: http.StatusCreated, Headers: map[string]string{"X-Id": "1"}, Body: map[string]interface{}{"id": "0123456789"}}
+	// the fault type is passed in the request, since the handler runs in the goroutines of the server
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		injectFault(&common.Fault{Type: r.URL.Query().Get("fault"), Probability: 1}, response, w)
+	}))
+	defer srv.Close()
+	get := func(faultType string) (*http.Response, error) {
+		return http.Get(srv.URL + "?fault=" + faultType)
+	}
 
-	// // wait
-	// time.Sleep(50 * time.Millisecond)
+	// no response at all
+	for _, faultType := range []string{common.FaultConnectionReset, common.FaultEmptyReply} {
+		_, err := get(faultType)
+		assert.NotNil(t, err, faultType)
+	}
 
-	// assert.Equal(t, 1, len(mockapi.GetMockApiList()))
+	// the body is cut off
+	resp, err := get(common.FaultTruncatedBody)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("X-Id"))
+	_, err = io.ReadAll(resp.Body)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	resp.Body.Close()
+
+	// the body is complete, but it is not a valid json
+	resp, err = get(common.FaultMalformedJson)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	assert.False(t, json.Valid(body))
+	resp.Body.Close()
+
+	// 5xx error
+	resp, err = get(common.FaultRandom5xx)
+	assert.Nil(t, err)
+	assert.Contains(t, faultStatusCodes, resp.StatusCode)
+	resp.Body.Close()
+}
 
-	// // generate request
-	// url := mockApi.Name
-	// r := httptest.NewRecorder()
+func TestScenarios(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	defer func() { closeCh <- true }()
 
-	// // test get response of the MockApi
-	// webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", url, nil))
-	// assert.Equal(t, http.StatusNoContent, r.Code)
+	// wait
+	time.Sleep(50 * time.Millisecond)
 
-	// // wait
-	// time.Sleep(50 * time.Millisecond)
+	// write mock api
+	id, _, mockApi := writeDummyMockApiFile(t)
+	defer func() {
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFile(t, id)
+	}()
 
-	// TODO: complete the test
+	// wait
+	time.Sleep(50 * time.Millisecond)
 
+	mockApi.Scenario = "job"
+	if json.Unmarshal([]byte(`{"sequence":[{"body":{"job":"pending"}},{"body":{"job":"done"},"newState":"Done"}],
+		"candidates":[{"state":"Done","match":{},"status":410}]}`), &mockApi.Responses.Get) != nil {
+		t.Fatalf("error while unmarshalling")
+	}
+	bytesPut, err := json.Marshal(mockApi)
+	if err != nil {
+		t.Fatalf("error while marshalign object : %s", err)
+	}
+	r := httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("PUT", "/dynamocker/api/mock-api/"+id, bytes.NewBuffer(bytesPut)))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	serve := func() *httptest.ResponseRecorder {
+		r := httptest.NewRecorder()
+		webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/serve-mock-api/"+mockApi.URL, nil))
+		return r
+	}
+	getState := func() string {
+		r := httptest.NewRecorder()
+		webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/scenarios/job", nil))
+		assert.Equal(t, http.StatusOK, r.Code)
+		var scenario ScenarioState
+		if err := json.Unmarshal(r.Body.Bytes(), &scenario); err != nil {
+			t.Fatalf("error while unmarshalling: %s", err)
+		}
+		return scenario.State
+	}
+
+	assert.Equal(t, "Started", getState())
+	assert.JSONEq(t, `{"job":"pending"}`, serve().Body.String())
+	assert.JSONEq(t, `{"job":"done"}`, serve().Body.String())
+	assert.Equal(t, "Done", getState())
+	assert.Equal(t, http.StatusGone, serve().Code)
+
+	// list the scenarios
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/scenarios", nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.JSONEq(t, `[{"name":"job","state":"Done"}]`, r.Body.String())
+
+	// reset the scenario
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("DELETE", "/dynamocker/api/scenarios/job", nil))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+	assert.Equal(t, "Started", getState())
+	assert.JSONEq(t, `{"job":"pending"}`, serve().Body.String())
+
+	// set the state
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("PUT", "/dynamocker/api/scenarios/job", strings.NewReader(`{"state":"Done"}`)))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+	assert.Equal(t, http.StatusGone, serve().Code)
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("PUT", "/dynamocker/api/scenarios/job", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, r.Code)
+
+	// unknown scenario
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/scenarios/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, r.Code)
+
+	// reset all
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("DELETE", "/dynamocker/api/scenarios", nil))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+	assert.Equal(t, "Started", getState())
+
+	// HEAD requests move the sequence of GET on
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("HEAD", "/dynamocker/api/serve-mock-api/"+mockApi.URL, nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.JSONEq(t, `{"job":"done"}`, serve().Body.String())
+	assert.Equal(t, "Done", getState())
+
+	// the responses replaced by a fault count as served
+	mockApi.Faults = []common.Fault{{Type: common.FaultRandom5xx, Probability: 1}}
+	bytesPut, err = json.Marshal(mockApi)
+	if err != nil {
+		t.Fatalf("error while marshalign object : %s", err)
+	}
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("PUT", "/dynamocker/api/mock-api/"+id, bytes.NewBuffer(bytesPut)))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("DELETE", "/dynamocker/api/scenarios/job", nil))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+	assert.Contains(t, faultStatusCodes, serve().Code)
+	assert.Contains(t, faultStatusCodes, serve().Code)
+	assert.Equal(t, "Done", getState())
+}
+
+func TestRequestJournal(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	defer func() { closeCh <- true }()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	// write mock api
+	id, _, mockApi := writeDummyMockApiFile(t)
+	defer func() {
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFile(t, id)
+	}()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	r := httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("DELETE", "/dynamocker/api/requests", nil))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+
+	// serve a matched and an unmatched request
+	req := httptest.NewRequest("POST", "/dynamocker/api/serve-mock-api/"+mockApi.URL+"?id=1", strings.NewReader(`{"amount":10}`))
+	req.Header.Set("X-Request-Id", "abc")
+	webServerTest.router.ServeHTTP(httptest.NewRecorder(), req)
+	webServerTest.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/dynamocker/api/serve-mock-api/not/existing", nil))
+
+	getRequests := func(query string) []requestjournalpkg.Entry {
+		r := httptest.NewRecorder()
+		webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/requests"+query, nil))
+		assert.Equal(t, http.StatusOK, r.Code)
+		var entries []requestjournalpkg.Entry
+		if err := json.Unmarshal(r.Body.Bytes(), &entries); err != nil {
+			t.Fatalf("error while unmarshalling: %s", err)
+		}
+		return entries
+	}
+
+	entries := getRequests("")
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "POST", entries[0].Method)
+	assert.Equal(t, "/dynamocker/api/serve-mock-api/"+mockApi.URL+"?id=1", entries[0].URL)
+	assert.Equal(t, mockApi.URL, entries[0].Path)
+	assert.Equal(t, "abc", entries[0].Headers.Get("X-Request-Id"))
+	assert.Equal(t, `{"amount":10}`, entries[0].Body)
+	assert.Equal(t, id, entries[0].MockApiId)
+	assert.Equal(t, mockApi.Name, entries[0].MockApiName)
+	assert.Equal(t, http.StatusOK, entries[0].Status)
+	assert.Empty(t, entries[1].MockApiId)
+	assert.Equal(t, http.StatusNotFound, entries[1].Status)
+
+	// filters
+	assert.Equal(t, 1, len(getRequests("?method=POST")))
+	assert.Equal(t, 1, len(getRequests("?unmatched=true")))
+	assert.Equal(t, 1, len(getRequests("?mockApiId="+id)))
+	assert.Equal(t, 1, len(getRequests("?url=not/existing$")))
+	assert.Equal(t, 0, len(getRequests("?status=500")))
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/requests?since=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, r.Code)
+
+	// clear the journal
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("DELETE", "/dynamocker/api/requests", nil))
+	assert.Equal(t, http.StatusNoContent, r.Code)
+	assert.Empty(t, getRequests(""))
+}
+
+func TestVerifyRequests(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	defer func() { closeCh <- true }()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	// write mock api
+	id, _, mockApi := writeDummyMockApiFile(t)
+	defer func() {
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFile(t, id)
+	}()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	webServerTest.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/dynamocker/api/requests", nil))
+	webServerTest.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/dynamocker/api/serve-mock-api/"+mockApi.URL, strings.NewReader(`{"amount":10}`)))
+
+	verify := func(body string) (int, requestjournalpkg.VerificationResult) {
+		r := httptest.NewRecorder()
+		webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", "/dynamocker/api/requests/verify", strings.NewReader(body)))
+		var result requestjournalpkg.VerificationResult
+		if r.Code == http.StatusOK {
+			if err := json.Unmarshal(r.Body.Bytes(), &result); err != nil {
+				t.Fatalf("error while unmarshalling: %s", err)
+			}
+		}
+		return r.Code, result
+	}
+
+	code, result := verify(`{"method":"POST","url":"` + mockApi.URL + `","match":{"body":[{"jsonPath":"$.amount","equalTo":10}]},"count":{"exactly":1}}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, result.Passed)
+	assert.Equal(t, 1, result.Count)
+
+	code, result = verify(`{"method":"POST","match":{"body":[{"jsonPath":"$.amount","equalTo":20}]}}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, result.Passed)
+	assert.Equal(t, 0, result.Count)
+	assert.Equal(t, 1, len(result.NearMisses))
+
+	code, _ = verify(`{"count":"once"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = verify(`{"url":"**/users"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestProxyAndRecord(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	defer func() { closeCh <- true }()
+
+	// upstream counting the forwarded requests
+	var upstreamHits int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHits++
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/users/42":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Upstream", "real")
+			w.Write([]byte(`{"id":42,"query":"` + r.URL.RawQuery + `"}`))
+		case r.Method == "POST" && r.URL.Path == "/users/42":
+			w.WriteHeader(http.StatusCreated)
+		case r.URL.Path == "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 'P', 'N', 'G', 0xff})
+		case r.URL.Path == "/compressed":
+			w.Header().Set("Content-Type", "application/json")
+			if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+				w.Write([]byte(`{"compressed":false}`))
+				return
+			}
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write([]byte(`{"compressed":true}`))
+			gz.Close()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer upstream.Close()
+	if err := os.Setenv("DYNA_RECORD_UPSTREAM", upstream.URL); err != nil {
+		t.Fatalf("cannot set env variable: %s", err)
+	}
+	defer func() {
+		os.Unsetenv("DYNA_RECORD_UPSTREAM")
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFilesByPrefix(t, "recorded-")
+	}()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	serve := func(method string, url string) *httptest.ResponseRecorder {
+		r := httptest.NewRecorder()
+		webServerTest.router.ServeHTTP(r, httptest.NewRequest(method, "/dynamocker/api/serve-mock-api/"+url, nil))
+		return r
+	}
+
+	// the upstream response is returned and recorded
+	r := serve("GET", "users/42?x=1")
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, `{"id":42,"query":"x=1"}`, r.Body.String())
+	assert.Equal(t, "real", r.Header().Get("X-Upstream"))
+	r = serve("GET", "logo.png")
+	assert.Equal(t, []byte{0x89, 'P', 'N', 'G', 0xff}, r.Body.Bytes())
+	assert.Equal(t, 2, upstreamHits)
+
+	// wait for the watcher
+	time.Sleep(100 * time.Millisecond)
+
+	// the recorded mocks are served without reaching the upstream
+	r = serve("GET", "users/42")
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.JSONEq(t, `{"id":42,"query":"x=1"}`, r.Body.String())
+	assert.Equal(t, "real", r.Header().Get("X-Upstream"))
+	r = serve("GET", "logo.png")
+	assert.Equal(t, "image/png", r.Header().Get("Content-Type"))
+	assert.Equal(t, []byte{0x89, 'P', 'N', 'G', 0xff}, r.Body.Bytes())
+	assert.Equal(t, 2, upstreamHits)
+
+	// a new method is added to the recorded mock
+	r = serve("POST", "users/42")
+	assert.Equal(t, http.StatusCreated, r.Code)
+	assert.Empty(t, r.Header().Get("Allow"))
+	assert.Equal(t, 3, upstreamHits)
+
+	// wait for the watcher
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusCreated, serve("POST", "users/42").Code)
+	assert.Equal(t, http.StatusOK, serve("GET", "users/42").Code)
+	assert.Equal(t, 3, upstreamHits)
+
+	// the responses recorded before the watcher loads the mockApi are added to it
+	var wg sync.WaitGroup
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+		wg.Add(1)
+		go func(method string) {
+			defer wg.Done()
+			res := &proxypkg.Response{Status: http.StatusNoContent, Headers: http.Header{}}
+			assert.Nil(t, recordResponse(method, "orders/7", res, "", nil))
+		}(method)
+	}
+	wg.Wait()
+	_, recorded, found := mockapipkg.FindApiByUrl("orders/7")
+	assert.True(t, found)
+	assert.Equal(t, 3, len(recorded.Responses.ByMethod()))
+
+	// the responses are recorded uncompressed, whatever the client accepts
+	r = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/dynamocker/api/serve-mock-api/compressed", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	webServerTest.router.ServeHTTP(r, req)
+	assert.Empty(t, r.Header().Get("Content-Encoding"))
+	assert.JSONEq(t, `{"compressed":true}`, r.Body.String())
+	_, recorded, found = mockapipkg.FindApiByUrl("compressed")
+	assert.True(t, found)
+	assert.Equal(t, map[string]interface{}{"compressed": true}, recorded.Responses.Get.Body)
+	assert.Empty(t, recorded.Responses.Get.Headers["Content-Encoding"])
+
+	// unreachable upstream
+	os.Setenv("DYNA_RECORD_UPSTREAM", "http://127.0.0.1:1")
+	assert.Equal(t, http.StatusBadGateway, serve("GET", "not/recorded").Code)
+}
+
+func TestPassThroughProxy(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	defer func() { closeCh <- true }()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	// write mock api
+	id, _, mockApi := writeDummyMockApiFile(t)
+	defer func() {
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFile(t, id)
+	}()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Token", r.Header.Get("X-Token"))
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer upstream.Close()
+	for env, value := range map[string]string{
+		"DYNA_PROXY_UPSTREAM":    upstream.URL,
+		"DYNA_PROXY_EXCLUDE":     "admin/**",
+		"DYNA_PROXY_SET_HEADERS": "X-Token:secret",
+	} {
+		if err := os.Setenv(env, value); err != nil {
+			t.Fatalf("cannot set env variable: %s", err)
+		}
+		defer os.Unsetenv(env)
+	}
+	if err := proxypkg.Init(); err != nil {
+		t.Fatalf("error initiating the proxy: %s", err)
+	}
+	defer proxypkg.Init()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	serve := func(method string, url string) *httptest.ResponseRecorder {
+		r := httptest.NewRecorder()
+		webServerTest.router.ServeHTTP(r, httptest.NewRequest(method, "/dynamocker/api/serve-mock-api/"+url, nil))
+		return r
+	}
+
+	// unmatched urls are passed through, with the headers rewritten
+	r := serve("GET", "users/1")
+	assert.Equal(t, http.StatusTeapot, r.Code)
+	assert.Equal(t, "/users/1", r.Header().Get("X-Path"))
+	assert.Equal(t, "secret", r.Header().Get("X-Token"))
+
+	// excluded urls are not
+	assert.Equal(t, http.StatusNotFound, serve("GET", "admin/users").Code)
+
+	// mocked urls are served by the mocks, the methods they don't define are not allowed
+	assert.Equal(t, http.StatusOK, serve("GET", mockApi.URL).Code)
+	r = serve("PUT", mockApi.URL)
+	assert.Equal(t, http.StatusMethodNotAllowed, r.Code)
+	assert.Equal(t, "DELETE, GET, HEAD, OPTIONS, PATCH, POST", r.Header().Get("Allow"))
+	assert.Empty(t, r.Header().Get("X-Path"))
+
+	// unreachable upstream
+	upstream.Close()
+	assert.Equal(t, http.StatusBadGateway, serve("GET", "users/1").Code)
+}
+
+func TestImportOpenApi(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	defer func() {
+		closeCh <- true
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFilesByPrefix(t, "imported-")
+	}()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	doc := `
+openapi: 3.0.0
+info:
+  title: Imported
+  version: "1"
+paths:
+  /users/{id}:
+    get:
+      responses:
+        '200':
+          description: user
+          content:
+            application/json:
+              example: {"id": 7}
+    trace:
+      responses:
+        '200':
+          description: trace
+`
+	r := httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", "/dynamocker/api/import/openapi", strings.NewReader(doc)))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.JSONEq(t, `{"imported":["imported-users-id"],"skipped":["TRACE /users/{id}: method not supported"]}`, r.Body.String())
+
+	// wait
+	time.Sleep(100 * time.Millisecond)
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/serve-mock-api/users/3", nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.JSONEq(t, `{"id":7}`, r.Body.String())
+
+	// the mockApis already stored are not imported twice
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", "/dynamocker/api/import/openapi", strings.NewReader(doc)))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.JSONEq(t, `{"imported":[],"skipped":["TRACE /users/{id}: method not supported","mockApi 'imported-users-id' not imported: found another mockApi with the same name 'imported-users-id'"]}`, r.Body.String())
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", "/dynamocker/api/import/openapi", strings.NewReader(`{"swagger":"2.0"}`)))
+	assert.Equal(t, http.StatusBadRequest, r.Code)
+}
+
+func TestImportHar(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	defer func() {
+		closeCh <- true
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFilesByPrefix(t, "har-")
+	}()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	har := `{"log": {"entries": [
+		{
+			"request": {"method": "GET", "url": "https://shop.example.com/cart/items?x=1"},
+			"response": {"status": 200, "headers": [{"name": "X-Cart", "value": "1"}], "content": {"mimeType": "text/csv", "text": "id\n1\n"}}
+		},
+		{
+			"request": {"method": "TRACE", "url": "https://shop.example.com/cart/items"},
+			"response": {"status": 200, "headers": [], "content": {}}
+		}
+	]}}`
+	r := httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", "/dynamocker/api/import/har?keepHost=true&keepHeaders=true", strings.NewReader(har)))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.JSONEq(t, `{"imported":["har-shop-example-com-cart-items"],"skipped":["entry 1 (TRACE https://shop.example.com/cart/items): method not supported"]}`, r.Body.String())
+
+	// wait
+	time.Sleep(100 * time.Millisecond)
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/serve-mock-api/shop.example.com/cart/items", nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "id\n1\n", r.Body.String())
+	assert.Equal(t, "text/csv", r.Header().Get("Content-Type"))
+	assert.Equal(t, "1", r.Header().Get("X-Cart"))
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", "/dynamocker/api/import/har", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, r.Code)
+}
+
+func TestPostmanImportExport(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	defer func() {
+		closeCh <- true
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFilesByPrefix(t, "qa-")
+	}()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	collection := `{
+		"info": {"name": "QA", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"item": [{
+			"name": "Get user",
+			"request": {"method": "GET", "header": [], "url": "{{baseUrl}}/users/:id"},
+			"response": [{"name": "ok", "code": 200, "header": [{"key": "Content-Type", "value": "application/json"}], "body": "{\"id\": 5}"}]
+		}]
+	}`
+	r := httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", "/dynamocker/api/import/postman", strings.NewReader(collection)))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.JSONEq(t, `{"imported":["qa-users-id"],"skipped":[]}`, r.Body.String())
+
+	// wait
+	time.Sleep(100 * time.Millisecond)
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/serve-mock-api/users/5", nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.JSONEq(t, `{"id":5}`, r.Body.String())
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "http://localhost:8150/dynamocker/api/mock-apis/postman", nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	var exported postmanpkg.Collection
+	if err := json.Unmarshal(r.Body.Bytes(), &exported); err != nil {
+		t.Fatalf("error while unmarshalling: %s", err)
+	}
+	assert.Equal(t, "http://localhost:8150/dynamocker/api/serve-mock-api", exported.Variable[0].Value)
+	found := false
+	for _, item := range exported.Item {
+		if item.Name == "qa-users-id GET" {
+			found = true
+			assert.Equal(t, "{{baseUrl}}/users/:id", item.Request.URL.Raw)
+			assert.Equal(t, 200, item.Response[0].Code)
+		}
+	}
+	assert.True(t, found)
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("POST", "/dynamocker/api/import/postman", strings.NewReader(`{"info": {}}`)))
+	assert.Equal(t, http.StatusBadRequest, r.Code)
+}
+
+func TestGetMockApisOpenApi(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	defer func() { closeCh <- true }()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	// write mock api
+	id, _, mockApi := writeDummyMockApiFile(t)
+	defer func() {
+		// wait
+		time.Sleep(50 * time.Millisecond)
+		removeMockApiFile(t, id)
+	}()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	r := httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/mock-apis/openapi", nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	var doc openapipkg.Document
+	if err := json.Unmarshal(r.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("error while unmarshalling: %s", err)
+	}
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	item, found := doc.Paths["/"+mockApi.URL]
+	assert.True(t, found)
+	assert.Equal(t, mockApi.Responses.Get.Body, item.Get.Responses["200"].Content["application/json"].Example)
+	assert.NotNil(t, item.Patch)
+	assert.NotNil(t, item.Post)
+	assert.NotNil(t, item.Delete)
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/mock-apis/openapi?format=yaml", nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/yaml", r.Header().Get("Content-Type"))
+	parsed, err := openapipkg.Parse(r.Body.Bytes())
+	assert.Nil(t, err)
+	assert.Contains(t, parsed.Paths, "/"+mockApi.URL)
+}
+
+// remove the files of the mockApis whose name starts with the prefix
+func TestWireMockMappings(t *testing.T) {
+	// setup server and mockApi mgmt
+	closeCh, webServerTest := setup(t)
+	mappingFile := os.TempDir() + "/qa-wiremock.json"
+	defer func() {
+		os.Remove(mappingFile)
+		closeCh <- true
+		// wait
+		time.Sleep(50 * time.Millisecond)
+	}()
+
+	// wait
+	time.Sleep(50 * time.Millisecond)
+
+	mapping := `{
+		"mappings": [
+			{"request": {"method": "GET", "urlPathTemplate": "/qa-wiremock/{id}"}, "response": {"status": 200, "jsonBody": {"found": true}}},
+			{"request": {"method": "GET", "urlPathTemplate": "/qa-wiremock/{id}", "pathParameters": {"id": {"equalTo": "0"}}}, "response": {"status": 404}}
+		]
+	}`
+	if err := os.WriteFile(mappingFile, []byte(mapping), 0644); err != nil {
+		t.Fatalf("error while writing the mapping file: %s", err)
+	}
+
+	// wait
+	time.Sleep(100 * time.Millisecond)
+
+	r := httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/serve-mock-api/qa-wiremock/5", nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.JSONEq(t, `{"found":true}`, r.Body.String())
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/serve-mock-api/qa-wiremock/0", nil))
+	assert.Equal(t, http.StatusNotFound, r.Code)
+
+	r = httptest.NewRecorder()
+	webServerTest.router.ServeHTTP(r, httptest.NewRequest("GET", "/dynamocker/api/mock-apis/wiremock", nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	var exported wiremockpkg.Mappings
+	if err := json.Unmarshal(r.Body.Bytes(), &exported); err != nil {
+		t.Fatalf("error while unmarshalling: %s", err)
+	}
+	found := 0
+	for _, mapping := range exported.Mappings {
+		if mapping.Name == "qa-wiremock" {
+			found++
+			assert.Equal(t, "/qa-wiremock/{id}", mapping.Request.URLPathTemplate)
+		}
+	}
+	assert.Equal(t, 2, found)
+}
+
+func removeMockApiFilesByPrefix(t *testing.T, prefix string) {
+	files, err := os.ReadDir(os.TempDir())
+	if err != nil {
+		t.Fatalf("error while reading the temp folder: %s", err)
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		filePath := os.TempDir() + "/" + file.Name()
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			continue
+		}
+		var mockApi common.MockApi
+		if json.Unmarshal(content, &mockApi) == nil && strings.HasPrefix(mockApi.Name, prefix) {
+			if err := os.Remove(filePath); err != nil {
+				t.Fatalf("file not removed: %s", err)
+			}
+		}
+	}
 }
 
-func removeMockApiFile(t *testing.T, uuid uint16) {
+func removeMockApiFile(t *testing.T, id string) {
 
-	filePath := os.TempDir() + "/" + fmt.Sprintf("%d", uuid) + ".json"
+	filePath := os.TempDir() + "/" + id + ".json"
 	_, err := os.Stat(filePath)
 	if err == nil {
 		err = os.Remove(filePath)
@@ -0,0 +1,233 @@
+package wiremockpkg
+
+import (
+	"dynamocker/internal/common"
+	urlpatternpkg "dynamocker/internal/url-pattern"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FromMockApis describes the mockApis as WireMock stub mappings, named after
+// the mockApi, with a mapping per method of each mockApi and a mapping per
+// candidate. Candidates get a higher priority than the default response, in
+// the order they are evaluated by dynamocker. Only the first response of the
+// sequences is exported, the faults are not exported.
+func FromMockApis(mockApis []*common.MockApi) Mappings {
+	mappings := Mappings{Mappings: make([]Mapping, 0)}
+
+	sorted := append(make([]*common.MockApi, 0, len(mockApis)), mockApis...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	for _, mockApi := range sorted {
+		pattern, err := urlpatternpkg.Compile(mockApi.URL)
+		if err != nil {
+			continue
+		}
+		request := exportUrl(pattern)
+
+		responses := mockApi.Responses.ByMethod()
+		methods := make([]string, 0, len(responses))
+		for method := range responses {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			response := responses[method]
+			for i, candidate := range response.Candidates {
+				mapping := Mapping{
+					Name:     mockApi.Name,
+					Priority: i + 1,
+					Request:  exportMatcher(request, candidate.Match),
+					Response: exportResponse(first(candidate.ResponseDef, candidate.Sequence), mockApi.Delay),
+				}
+				mapping.Request.Method = method
+				if candidate.State != "" {
+					mapping.ScenarioName = mockApi.Scenario
+					mapping.RequiredScenarioState = candidate.State
+				}
+				mappings.Mappings = append(mappings.Mappings, withNewState(mapping, mockApi, candidate.ResponseDef))
+			}
+
+			// without default response, the requests not matching the candidates are not served
+			if response.ResponseDef.IsEmpty() && len(response.Sequence) == 0 {
+				continue
+			}
+			def := first(response.ResponseDef, response.Sequence)
+			mapping := Mapping{
+				Name:     mockApi.Name,
+				Priority: len(response.Candidates) + 1,
+				Request:  request,
+				Response: exportResponse(def, mockApi.Delay),
+			}
+			mapping.Request.Method = method
+			mappings.Mappings = append(mappings.Mappings, withNewState(mapping, mockApi, def))
+		}
+	}
+	return mappings
+}
+
+// url of the request: the literal urls are matched by path, the urls with
+// plain parameters by template and the other ones by regex
+func exportUrl(pattern *urlpatternpkg.Pattern) RequestPattern {
+	template, params := pattern.Template()
+	if len(params) == 0 {
+		return RequestPattern{URLPath: "/" + template}
+	}
+	plain := true
+	for _, param := range params {
+		plain = plain && param.Regex == "" && !param.CatchAll && !strings.HasPrefix(param.Name, "wildcard")
+	}
+	if plain {
+		return RequestPattern{URLPathTemplate: "/" + template}
+	}
+
+	regexParams := make(map[string]urlpatternpkg.Param, len(params))
+	for _, param := range params {
+		regexParams["{"+param.Name+"}"] = param
+	}
+	parts := make([]string, 0)
+	for _, segment := range strings.Split(template, "/") {
+		param, found := regexParams[segment]
+		switch {
+		case !found:
+			parts = append(parts, regexp.QuoteMeta(segment))
+		case param.CatchAll:
+			parts = append(parts, ".*")
+		case strings.Contains(param.Regex, "|"):
+			parts = append(parts, "(?:"+param.Regex+")")
+		case param.Regex != "":
+			parts = append(parts, param.Regex)
+		default:
+			parts = append(parts, "[^/]+")
+		}
+	}
+	return RequestPattern{URLPathPattern: "^/" + strings.Join(parts, "/") + "$"}
+}
+
+func exportMatcher(request RequestPattern, matcher common.RequestMatcher) RequestPattern {
+	// path parameters can be matched only by the url templates
+	if request.URLPathTemplate != "" {
+		request.PathParameters = exportValues(matcher.PathParams)
+	}
+	request.QueryParameters = exportValues(matcher.Query)
+	request.Headers = exportValues(matcher.Headers)
+	request.Cookies = exportValues(matcher.Cookies)
+	for _, body := range matcher.Body {
+		request.BodyPatterns = append(request.BodyPatterns, exportBody(body))
+	}
+	return request
+}
+
+func exportValues(matchers map[string]common.ValueMatcher) map[string]ValuePattern {
+	if len(matchers) == 0 {
+		return nil
+	}
+	patterns := make(map[string]ValuePattern, len(matchers))
+	for name, matcher := range matchers {
+		patterns[name] = exportValue(matcher)
+	}
+	return patterns
+}
+
+func exportValue(matcher common.ValueMatcher) ValuePattern {
+	switch {
+	case matcher.Absent:
+		return ValuePattern{Absent: true}
+	case matcher.EqualTo != nil:
+		value := fmt.Sprint(matcher.EqualTo)
+		return ValuePattern{EqualTo: &value}
+	case matcher.Matches != "":
+		return ValuePattern{Matches: fullMatch(matcher.Matches)}
+	default:
+		// the value must be present
+		return ValuePattern{Matches: ".*"}
+	}
+}
+
+func exportBody(matcher common.BodyMatcher) BodyPattern {
+	switch {
+	case matcher.JsonPath == "$" && matcher.EqualTo != nil:
+		return BodyPattern{EqualToJson: matcher.EqualTo}
+	case matcher.JsonPath != "":
+		if matcher.EqualTo == nil && matcher.Matches == "" && !matcher.Absent {
+			return BodyPattern{MatchesJsonPath: matcher.JsonPath}
+		}
+		return BodyPattern{MatchesJsonPath: JsonPathPattern{
+			Expression:   matcher.JsonPath,
+			ValuePattern: exportValue(matcher.ValueMatcher),
+		}}
+	default:
+		value := exportValue(matcher.ValueMatcher)
+		return BodyPattern{EqualTo: value.EqualTo, Matches: value.Matches}
+	}
+}
+
+// the regex of dynamocker matches any part of the value, the ones of
+// WireMock the whole value
+func fullMatch(regex string) string {
+	if strings.HasPrefix(regex, "^(?:") && strings.HasSuffix(regex, ")$") {
+		return regex[4 : len(regex)-2]
+	}
+	return ".*(?:" + regex + ").*"
+}
+
+func exportResponse(def common.ResponseDef, delay *common.Delay) ResponseDefinition {
+	response := ResponseDefinition{Status: def.StatusCode()}
+	if len(def.Headers) > 0 || def.Body != nil {
+		response.Headers = make(map[string]interface{})
+		for name, value := range def.Headers {
+			response.Headers[http.CanonicalHeaderKey(name)] = value
+		}
+		if def.Body != nil {
+			response.Headers["Content-Type"] = def.ContentType()
+		}
+	}
+	if def.Body != nil {
+		switch def.BodyType {
+		case common.BodyTypeText:
+			body := fmt.Sprint(def.Body)
+			response.Body = &body
+		case common.BodyTypeBase64:
+			if body, err := def.BodyBytes(); err == nil {
+				response.Base64Body = base64.StdEncoding.EncodeToString(body)
+			}
+		default:
+			response.JsonBody = def.Body
+		}
+	}
+
+	if def.Delay != nil {
+		delay = def.Delay
+	}
+	if delay != nil {
+		switch delay.Type {
+		case common.DelayTypeFixed:
+			response.FixedDelayMilliseconds = delay.Milliseconds
+		case common.DelayTypeUniform:
+			response.DelayDistribution = &DelayDistribution{Type: "uniform", Lower: delay.Min, Upper: delay.Max}
+		case common.DelayTypeLognormal:
+			response.DelayDistribution = &DelayDistribution{Type: "lognormal", Median: delay.Median, Sigma: delay.Sigma}
+		}
+	}
+	return response
+}
+
+func withNewState(mapping Mapping, mockApi *common.MockApi, def common.ResponseDef) Mapping {
+	if def.NewState != "" {
+		mapping.ScenarioName = mockApi.Scenario
+		mapping.NewScenarioState = def.NewState
+	}
+	return mapping
+}
+
+// first response served: the default one or the first of the sequence
+func first(def common.ResponseDef, sequence common.Sequence) common.ResponseDef {
+	if len(sequence) > 0 {
+		return sequence[0]
+	}
+	return def
+}
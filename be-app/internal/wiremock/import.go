@@ -0,0 +1,401 @@
+package wiremockpkg
+
+import (
+	"dynamocker/internal/common"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// methods served by the mappings using the ANY method
+var anyMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// faults of WireMock, converted into the closest fault of dynamocker
+var faults = map[string]string{
+	"CONNECTION_RESET_BY_PEER": common.FaultConnectionReset,
+	"EMPTY_RESPONSE":           common.FaultEmptyReply,
+	"MALFORMED_RESPONSE_CHUNK": common.FaultTruncatedBody,
+	"RANDOM_DATA_THEN_CLOSE":   common.FaultMalformedJson,
+}
+
+// ToMockApis converts the mappings of a file into a MockApi per url, in the
+// order the urls first appear, see ToMockApi. When the mappings serve several
+// urls, the MockApis whose first mapping has no name are named after
+// defaultName and their position (e.g. 'stubs-2').
+func ToMockApis(mappings []Mapping, defaultName string, filesDir string) ([]*common.MockApi, error) {
+	if len(mappings) == 0 {
+		return nil, fmt.Errorf("no mapping found")
+	}
+	urls := make([]string, 0)
+	groups := make(map[string][]Mapping)
+	for i, mapping := range mappings {
+		path, _, err := mockUrl(&mapping.Request)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %d: %s", i, err)
+		}
+		if _, found := groups[path]; !found {
+			urls = append(urls, path)
+		}
+		groups[path] = append(groups[path], mapping)
+	}
+
+	mockApis := make([]*common.MockApi, 0, len(urls))
+	for i, path := range urls {
+		name := defaultName
+		if len(urls) > 1 {
+			name = fmt.Sprintf("%s-%d", defaultName, i+1)
+		}
+		mockApi, err := ToMockApi(groups[path], name, filesDir)
+		if err != nil {
+			return nil, fmt.Errorf("url '%s': %s", path, err)
+		}
+		mockApis = append(mockApis, mockApi)
+	}
+	return mockApis, nil
+}
+
+// ToMockApi converts the mappings of a file into a MockApi. All the mappings
+// must serve the same url. The mappings with request conditions, or requiring
+// a scenario state, become candidates, sorted by priority. The body files are
+// read from filesDir. The name of the first mapping names the MockApi,
+// defaultName is used if it has no name. The faults apply to the whole
+// MockApi, so they are supported only by a mapping serving its url alone.
+func ToMockApi(mappings []Mapping, defaultName string, filesDir string) (*common.MockApi, error) {
+	if len(mappings) == 0 {
+		return nil, fmt.Errorf("no mapping found")
+	}
+	// the lower the priority, the earlier the mapping is evaluated (5 is the default)
+	sorted := append(make([]Mapping, 0, len(mappings)), mappings...)
+	sort.SliceStable(sorted, func(i, j int) bool { return priority(sorted[i]) < priority(sorted[j]) })
+
+	mockApi := common.MockApi{Name: mappings[0].Name}
+	if mockApi.Name == "" {
+		mockApi.Name = defaultName
+	}
+
+	for i, mapping := range sorted {
+		path, urlQuery, err := mockUrl(&mapping.Request)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %d: %s", i, err)
+		}
+		if mockApi.URL != "" && mockApi.URL != path {
+			return nil, fmt.Errorf("mapping %d: mappings serving different urls ('%s' and '%s') in the same file are not supported", i, mockApi.URL, path)
+		}
+		mockApi.URL = path
+
+		matcher, err := requestMatcher(&mapping.Request, urlQuery)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %d: %s", i, err)
+		}
+		def, err := responseDef(&mapping, filesDir)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %d: %s", i, err)
+		}
+		if mapping.ScenarioName != "" {
+			mockApi.Scenario = mapping.ScenarioName
+		}
+		if mapping.Response.Fault != "" && len(mappings) > 1 {
+			return nil, fmt.Errorf("mapping %d: fault '%s' not supported along with other mappings of the same url", i, mapping.Response.Fault)
+		}
+		if fault, found := faults[mapping.Response.Fault]; found {
+			mockApi.Faults = append(mockApi.Faults, common.Fault{Type: fault, Probability: 1})
+		} else if mapping.Response.Fault != "" {
+			return nil, fmt.Errorf("mapping %d: fault '%s' not supported", i, mapping.Response.Fault)
+		}
+
+		methods := []string{strings.ToUpper(mapping.Request.Method)}
+		if methods[0] == "ANY" || methods[0] == "" {
+			methods = anyMethods
+		}
+		added := false
+		for _, method := range methods {
+			response, found := mockApi.Responses.ByMethod()[method]
+			if !found {
+				response = &common.MethodResponse{}
+			}
+			conditional := !isEmptyMatcher(matcher) || mapping.RequiredScenarioState != ""
+			if !conditional && response.ResponseDef.IsEmpty() {
+				response.ResponseDef = *def
+			} else {
+				// the unconditional mappings after the first one match any request
+				response.Candidates = append(response.Candidates, common.Candidate{
+					Match:       matcher,
+					State:       mapping.RequiredScenarioState,
+					ResponseDef: *def,
+				})
+			}
+			if mockApi.Responses.SetByMethod(method, response) {
+				added = true
+			}
+		}
+		if !added {
+			return nil, fmt.Errorf("mapping %d: method %s not supported", i, mapping.Request.Method)
+		}
+	}
+	return &mockApi, nil
+}
+
+func priority(mapping Mapping) int {
+	if mapping.Priority == 0 {
+		return 5
+	}
+	return mapping.Priority
+}
+
+// url of the MockApi and query parameters required by the url of the mapping
+func mockUrl(request *RequestPattern) (string, url.Values, error) {
+	switch {
+	case request.URL != "":
+		parsed, err := url.Parse(request.URL)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid url '%s': %s", request.URL, err)
+		}
+		return literalUrl(parsed.Path), parsed.Query(), nil
+	case request.URLPath != "":
+		return literalUrl(request.URLPath), nil, nil
+	case request.URLPathTemplate != "":
+		return strings.Trim(request.URLPathTemplate, "/"), nil, nil
+	case request.URLPathPattern != "":
+		path, err := regexToPattern(request.URLPathPattern)
+		return path, nil, err
+	case request.URLPattern != "":
+		// the query part of the regex is ignored
+		path, _, _ := strings.Cut(request.URLPattern, `\?`)
+		path, err := regexToPattern(path)
+		return path, nil, err
+	default:
+		// no url: any url matches
+		return "**", nil, nil
+	}
+}
+
+func literalUrl(path string) string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "**"
+	}
+	return path
+}
+
+var regexMeta = regexp.MustCompile(`[\\.+*?()|\[\]{}^$]`)
+
+// convert a regex matching the path into a url pattern, segment by segment:
+// literal segments are kept, '.*' at the end becomes '**', segments matching
+// any value become '*' and the other ones become regex parameters
+func regexToPattern(regex string) (string, error) {
+	regex = strings.TrimSuffix(strings.TrimPrefix(regex, "^"), "$")
+	segments := splitRegex(strings.Trim(regex, "/"))
+	parts := make([]string, 0, len(segments))
+	params := 0
+	for i, segment := range segments {
+		switch {
+		case !regexMeta.MatchString(segment):
+			parts = append(parts, segment)
+		case (segment == ".*" || segment == "(.*)") && i == len(segments)-1:
+			parts = append(parts, "**")
+		case segment == "[^/]+" || segment == ".+" || segment == ".*" || segment == "([^/]+)" || segment == "(.+)":
+			parts = append(parts, "*")
+		default:
+			if _, err := regexp.Compile(segment); err != nil {
+				return "", fmt.Errorf("the url regex '%s' can't be converted: %s", regex, err)
+			}
+			params++
+			parts = append(parts, fmt.Sprintf("{p%d:%s}", params, segment))
+		}
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+func requestMatcher(request *RequestPattern, urlQuery url.Values) (common.RequestMatcher, error) {
+	var matcher common.RequestMatcher
+	var err error
+	if matcher.PathParams, err = valueMatchers(request.PathParameters); err != nil {
+		return matcher, fmt.Errorf("path parameters: %s", err)
+	}
+	if matcher.Query, err = valueMatchers(request.QueryParameters); err != nil {
+		return matcher, fmt.Errorf("query parameters: %s", err)
+	}
+	for key := range urlQuery {
+		if matcher.Query == nil {
+			matcher.Query = make(map[string]common.ValueMatcher)
+		}
+		matcher.Query[key] = common.ValueMatcher{EqualTo: urlQuery.Get(key)}
+	}
+	if matcher.Headers, err = valueMatchers(request.Headers); err != nil {
+		return matcher, fmt.Errorf("headers: %s", err)
+	}
+	if matcher.Cookies, err = valueMatchers(request.Cookies); err != nil {
+		return matcher, fmt.Errorf("cookies: %s", err)
+	}
+	for i, pattern := range request.BodyPatterns {
+		bodyMatcher, err := bodyMatcher(pattern)
+		if err != nil {
+			return matcher, fmt.Errorf("body pattern %d: %s", i, err)
+		}
+		matcher.Body = append(matcher.Body, bodyMatcher)
+	}
+	return matcher, nil
+}
+
+func valueMatchers(patterns map[string]ValuePattern) (map[string]common.ValueMatcher, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	matchers := make(map[string]common.ValueMatcher, len(patterns))
+	for name, pattern := range patterns {
+		matcher, err := valueMatcher(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("'%s': %s", name, err)
+		}
+		matchers[name] = matcher
+	}
+	return matchers, nil
+}
+
+func valueMatcher(pattern ValuePattern) (common.ValueMatcher, error) {
+	switch {
+	case pattern.DoesNotMatch != "":
+		return common.ValueMatcher{}, fmt.Errorf("doesNotMatch not supported")
+	case pattern.EqualTo != nil:
+		return common.ValueMatcher{EqualTo: *pattern.EqualTo}, nil
+	case pattern.Matches != "":
+		return common.ValueMatcher{Matches: "^(?:" + pattern.Matches + ")$"}, nil
+	case pattern.Contains != "":
+		return common.ValueMatcher{Matches: regexp.QuoteMeta(pattern.Contains)}, nil
+	default:
+		return common.ValueMatcher{Absent: pattern.Absent}, nil
+	}
+}
+
+func bodyMatcher(pattern BodyPattern) (common.BodyMatcher, error) {
+	switch {
+	case pattern.EqualToJson != nil:
+		// json given as string is decoded, to be compared regardless of the formatting
+		expected := pattern.EqualToJson
+		if text, ok := expected.(string); ok {
+			if err := json.Unmarshal([]byte(text), &expected); err != nil {
+				return common.BodyMatcher{}, fmt.Errorf("invalid equalToJson: %s", err)
+			}
+		}
+		return common.BodyMatcher{JsonPath: "$", ValueMatcher: common.ValueMatcher{EqualTo: expected}}, nil
+	case pattern.MatchesJsonPath != nil:
+		if expression, ok := pattern.MatchesJsonPath.(string); ok {
+			return common.BodyMatcher{JsonPath: expression}, nil
+		}
+		data, err := json.Marshal(pattern.MatchesJsonPath)
+		if err != nil {
+			return common.BodyMatcher{}, err
+		}
+		var jsonPath JsonPathPattern
+		if err := json.Unmarshal(data, &jsonPath); err != nil {
+			return common.BodyMatcher{}, fmt.Errorf("invalid matchesJsonPath: %s", err)
+		}
+		matcher, err := valueMatcher(jsonPath.ValuePattern)
+		return common.BodyMatcher{JsonPath: jsonPath.Expression, ValueMatcher: matcher}, err
+	default:
+		matcher, err := valueMatcher(ValuePattern{EqualTo: pattern.EqualTo, Matches: pattern.Matches, Contains: pattern.Contains})
+		return common.BodyMatcher{ValueMatcher: matcher}, err
+	}
+}
+
+// split the regex on the slashes which are not part of a character class or
+// of a group
+func splitRegex(regex string) []string {
+	segments := make([]string, 0)
+	depth, start := 0, 0
+	escaped := false
+	for i, char := range regex {
+		switch {
+		case escaped:
+			escaped = false
+		case char == '\\':
+			escaped = true
+		case char == '[' || char == '(':
+			depth++
+		case (char == ']' || char == ')') && depth > 0:
+			depth--
+		case char == '/' && depth == 0:
+			segments = append(segments, regex[start:i])
+			start = i + 1
+		}
+	}
+	return append(segments, regex[start:])
+}
+
+func isEmptyMatcher(matcher common.RequestMatcher) bool {
+	return len(matcher.PathParams) == 0 && len(matcher.Query) == 0 && len(matcher.Headers) == 0 &&
+		len(matcher.Cookies) == 0 && len(matcher.Body) == 0
+}
+
+func responseDef(mapping *Mapping, filesDir string) (*common.ResponseDef, error) {
+	response := &mapping.Response
+	status := response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	headers := http.Header{}
+	for name, value := range response.Headers {
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				headers.Add(name, fmt.Sprint(item))
+			}
+		default:
+			headers.Add(name, fmt.Sprint(v))
+		}
+	}
+
+	var def common.ResponseDef
+	switch {
+	case response.JsonBody != nil:
+		body, err := json.Marshal(response.JsonBody)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jsonBody: %s", err)
+		}
+		// converted as a json body, whatever the Content-Type set
+		if headers.Get("Content-Type") == "" {
+			headers.Set("Content-Type", "application/json")
+		}
+		def = common.NewResponseDef(status, headers, body)
+	case response.Base64Body != "":
+		body, err := base64.StdEncoding.DecodeString(response.Base64Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64Body: %s", err)
+		}
+		def = common.NewResponseDef(status, headers, body)
+	case response.BodyFileName != "":
+		body, err := os.ReadFile(filepath.Join(filesDir, filepath.Clean("/"+response.BodyFileName)))
+		if err != nil {
+			return nil, fmt.Errorf("error while reading the body file: %s", err)
+		}
+		def = common.NewResponseDef(status, headers, body)
+	case response.Body != nil:
+		def = common.NewResponseDef(status, headers, []byte(*response.Body))
+	default:
+		def = common.NewResponseDef(status, headers, nil)
+	}
+	def.NewState = mapping.NewScenarioState
+
+	switch {
+	case response.FixedDelayMilliseconds > 0:
+		def.Delay = &common.Delay{Type: common.DelayTypeFixed, Milliseconds: response.FixedDelayMilliseconds}
+	case response.DelayDistribution != nil:
+		distribution := response.DelayDistribution
+		switch distribution.Type {
+		case "lognormal":
+			def.Delay = &common.Delay{Type: common.DelayTypeLognormal, Median: distribution.Median, Sigma: distribution.Sigma}
+		case "uniform":
+			def.Delay = &common.Delay{Type: common.DelayTypeUniform, Min: distribution.Lower, Max: distribution.Upper}
+		default:
+			return nil, fmt.Errorf("delay distribution '%s' not supported", distribution.Type)
+		}
+	}
+	return &def, nil
+}
@@ -0,0 +1,115 @@
+package wiremockpkg
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Mappings is the content of a WireMock file holding several stub mappings
+type Mappings struct {
+	Mappings []Mapping `json:"mappings"`
+}
+
+// Mapping is the subset of a WireMock stub mapping supported by dynamocker
+type Mapping struct {
+	Id                    string             `json:"id,omitempty"`
+	Name                  string             `json:"name,omitempty"`
+	Priority              int                `json:"priority,omitempty"`
+	Request               RequestPattern     `json:"request"`
+	Response              ResponseDefinition `json:"response"`
+	ScenarioName          string             `json:"scenarioName,omitempty"`
+	RequiredScenarioState string             `json:"requiredScenarioState,omitempty"`
+	NewScenarioState      string             `json:"newScenarioState,omitempty"`
+}
+
+type RequestPattern struct {
+	Method          string                  `json:"method,omitempty"`
+	URL             string                  `json:"url,omitempty"`
+	URLPath         string                  `json:"urlPath,omitempty"`
+	URLPattern      string                  `json:"urlPattern,omitempty"`
+	URLPathPattern  string                  `json:"urlPathPattern,omitempty"`
+	URLPathTemplate string                  `json:"urlPathTemplate,omitempty"`
+	PathParameters  map[string]ValuePattern `json:"pathParameters,omitempty"`
+	QueryParameters map[string]ValuePattern `json:"queryParameters,omitempty"`
+	Headers         map[string]ValuePattern `json:"headers,omitempty"`
+	Cookies         map[string]ValuePattern `json:"cookies,omitempty"`
+	BodyPatterns    []BodyPattern           `json:"bodyPatterns,omitempty"`
+}
+
+type ValuePattern struct {
+	EqualTo      *string `json:"equalTo,omitempty"`
+	Matches      string  `json:"matches,omitempty"`
+	Contains     string  `json:"contains,omitempty"`
+	DoesNotMatch string  `json:"doesNotMatch,omitempty"`
+	Absent       bool    `json:"absent,omitempty"`
+}
+
+type BodyPattern struct {
+	EqualTo     *string     `json:"equalTo,omitempty"`
+	EqualToJson interface{} `json:"equalToJson,omitempty"`
+	// either the jsonPath expression or an object with the expression and a
+	// value pattern
+	MatchesJsonPath interface{} `json:"matchesJsonPath,omitempty"`
+	Matches         string      `json:"matches,omitempty"`
+	Contains        string      `json:"contains,omitempty"`
+}
+
+// JsonPathPattern is the object form of the matchesJsonPath body pattern
+type JsonPathPattern struct {
+	Expression string `json:"expression"`
+	ValuePattern
+}
+
+type ResponseDefinition struct {
+	Status int `json:"status,omitempty"`
+	// values are either strings or lists of strings
+	Headers                map[string]interface{} `json:"headers,omitempty"`
+	Body                   *string                `json:"body,omitempty"`
+	JsonBody               interface{}            `json:"jsonBody,omitempty"`
+	Base64Body             string                 `json:"base64Body,omitempty"`
+	BodyFileName           string                 `json:"bodyFileName,omitempty"`
+	FixedDelayMilliseconds int                    `json:"fixedDelayMilliseconds,omitempty"`
+	DelayDistribution      *DelayDistribution     `json:"delayDistribution,omitempty"`
+	Fault                  string                 `json:"fault,omitempty"`
+}
+
+type DelayDistribution struct {
+	Type   string  `json:"type"`
+	Median int     `json:"median,omitempty"`
+	Sigma  float64 `json:"sigma,omitempty"`
+	Lower  int     `json:"lower,omitempty"`
+	Upper  int     `json:"upper,omitempty"`
+}
+
+// IsMapping returns true if the json holds WireMock stub mappings: either a
+// single mapping, with request and response, or a list of mappings
+func IsMapping(data []byte) bool {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return false
+	}
+	_, hasMappings := raw["mappings"]
+	_, hasRequest := raw["request"]
+	_, hasResponse := raw["response"]
+	return hasMappings || (hasRequest && hasResponse)
+}
+
+// Parse reads a file holding either a single mapping or a list of mappings
+func Parse(data []byte) ([]Mapping, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid WireMock mapping: %s", err)
+	}
+	if _, found := raw["mappings"]; found {
+		var mappings Mappings
+		if err := json.Unmarshal(data, &mappings); err != nil {
+			return nil, fmt.Errorf("invalid WireMock mappings: %s", err)
+		}
+		return mappings.Mappings, nil
+	}
+	var mapping Mapping
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("invalid WireMock mapping: %s", err)
+	}
+	return []Mapping{mapping}, nil
+}
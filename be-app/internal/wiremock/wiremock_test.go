@@ -0,0 +1,212 @@
+package wiremockpkg
+
+import (
+	"dynamocker/internal/common"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const stubs = `{
+  "mappings": [
+    {
+      "name": "orders",
+      "request": {"method": "GET", "urlPathPattern": "/orders/[0-9]+"},
+      "response": {"status": 200, "jsonBody": {"id": 1}, "fixedDelayMilliseconds": 100}
+    },
+    {
+      "priority": 1,
+      "request": {
+        "method": "GET",
+        "urlPathPattern": "/orders/[0-9]+",
+        "headers": {"Authorization": {"absent": true}},
+        "queryParameters": {"expand": {"equalTo": "items"}}
+      },
+      "response": {"status": 401, "body": "unauthorized", "headers": {"Content-Type": "text/plain"}}
+    },
+    {
+      "request": {
+        "method": "POST",
+        "urlPathPattern": "/orders/[0-9]+",
+        "bodyPatterns": [{"matchesJsonPath": {"expression": "$.amount", "matches": "[0-9]+"}}]
+      },
+      "response": {"status": 201, "delayDistribution": {"type": "uniform", "lower": 10, "upper": 20}},
+      "scenarioName": "checkout",
+      "newScenarioState": "paid"
+    }
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	assert.True(t, IsMapping([]byte(stubs)))
+	assert.True(t, IsMapping([]byte(`{"request": {}, "response": {}}`)))
+	assert.False(t, IsMapping([]byte(`{"name": "users", "url": "users", "responses": {}}`)))
+	assert.False(t, IsMapping([]byte(`not json`)))
+
+	mappings, err := Parse([]byte(stubs))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(mappings))
+
+	mappings, err = Parse([]byte(`{"request": {"url": "/users"}, "response": {"status": 204}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 204, mappings[0].Response.Status)
+}
+
+func TestToMockApi(t *testing.T) {
+	mappings, err := Parse([]byte(stubs))
+	assert.Nil(t, err)
+
+	mockApi, err := ToMockApi(mappings, "file-name", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "orders", mockApi.Name)
+	assert.Equal(t, "orders/{p1:[0-9]+}", mockApi.URL)
+	assert.Equal(t, "checkout", mockApi.Scenario)
+
+	get := mockApi.Responses.Get
+	assert.Equal(t, map[string]interface{}{"id": float64(1)}, get.Body)
+	assert.Equal(t, &common.Delay{Type: common.DelayTypeFixed, Milliseconds: 100}, get.Delay)
+	// the mapping with conditions becomes a candidate
+	assert.Equal(t, 1, len(get.Candidates))
+	assert.Equal(t, 401, get.Candidates[0].Status)
+	assert.Equal(t, common.BodyTypeText, get.Candidates[0].BodyType)
+	assert.Equal(t, "unauthorized", get.Candidates[0].Body)
+	assert.True(t, get.Candidates[0].Match.Headers["Authorization"].Absent)
+	assert.Equal(t, "items", get.Candidates[0].Match.Query["expand"].EqualTo)
+
+	post := mockApi.Responses.Post
+	assert.Equal(t, "$.amount", post.Candidates[0].Match.Body[0].JsonPath)
+	assert.Equal(t, "^(?:[0-9]+)$", post.Candidates[0].Match.Body[0].Matches)
+	assert.Equal(t, "paid", post.Candidates[0].NewState)
+	assert.Equal(t, &common.Delay{Type: common.DelayTypeUniform, Min: 10, Max: 20}, post.Candidates[0].Delay)
+
+	// mappings without a name take the given one, ANY serves all the methods
+	mappings, _ = Parse([]byte(`{"request": {"method": "ANY", "url": "/users?page=1"}, "response": {"fault": "EMPTY_RESPONSE"}}`))
+	mockApi, err = ToMockApi(mappings, "users", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "users", mockApi.Name)
+	assert.Equal(t, "users", mockApi.URL)
+	assert.Equal(t, []common.Fault{{Type: common.FaultEmptyReply, Probability: 1}}, mockApi.Faults)
+	assert.Equal(t, "1", mockApi.Responses.Delete.Candidates[0].Match.Query["page"].EqualTo)
+
+	// unsupported features are reported
+	mappings, _ = Parse([]byte(`{"mappings": [{"request": {"url": "/a"}, "response": {}}, {"request": {"url": "/b"}, "response": {}}]}`))
+	_, err = ToMockApi(mappings, "ab", "")
+	assert.EqualError(t, err, "mapping 1: mappings serving different urls ('a' and 'b') in the same file are not supported")
+	mappings, _ = Parse([]byte(`{"mappings": [{"request": {"url": "/a"}, "response": {"fault": "EMPTY_RESPONSE"}}, {"request": {"url": "/a", "headers": {"X": {"equalTo": "y"}}}, "response": {}}]}`))
+	_, err = ToMockApi(mappings, "a", "")
+	assert.EqualError(t, err, "mapping 0: fault 'EMPTY_RESPONSE' not supported along with other mappings of the same url")
+	mappings, _ = Parse([]byte(`{"request": {"urlPath": "/a", "headers": {"X": {"doesNotMatch": "y"}}}, "response": {}}`))
+	_, err = ToMockApi(mappings, "a", "")
+	assert.EqualError(t, err, "mapping 0: headers: 'X': doesNotMatch not supported")
+}
+
+func TestToMockApis(t *testing.T) {
+	// a MockApi per url, the unnamed ones are named after the position
+	mappings, _ := Parse([]byte(`{"mappings": [
+		{"request": {"method": "GET", "url": "/a"}, "response": {"status": 200}},
+		{"name": "b", "request": {"method": "GET", "url": "/b"}, "response": {"status": 201}},
+		{"request": {"method": "POST", "url": "/a"}, "response": {"status": 202}}
+	]}`))
+	mockApis, err := ToMockApis(mappings, "stubs", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(mockApis))
+	assert.Equal(t, "stubs-1", mockApis[0].Name)
+	assert.Equal(t, "a", mockApis[0].URL)
+	assert.Equal(t, 202, mockApis[0].Responses.Post.StatusCode())
+	assert.Equal(t, "b", mockApis[1].Name)
+	assert.Equal(t, 201, mockApis[1].Responses.Get.StatusCode())
+
+	// a single url keeps the given name
+	mappings, _ = Parse([]byte(`{"request": {"url": "/a"}, "response": {}}`))
+	mockApis, err = ToMockApis(mappings, "stubs", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "stubs", mockApis[0].Name)
+
+	// the fault can't be limited to one of the mappings of the url
+	mappings, _ = Parse([]byte(`{"mappings": [{"request": {"url": "/b"}, "response": {}}, {"request": {"url": "/a"}, "response": {"fault": "EMPTY_RESPONSE"}}, {"request": {"method": "POST", "url": "/a"}, "response": {}}]}`))
+	_, err = ToMockApis(mappings, "stubs", "")
+	assert.EqualError(t, err, "url 'a': mapping 0: fault 'EMPTY_RESPONSE' not supported along with other mappings of the same url")
+}
+
+func TestToMockApiBodyFile(t *testing.T) {
+	filesDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(filesDir, "report.csv"), []byte("a,b\n1,2\n"), 0644); err != nil {
+		t.Fatalf("error while writing the body file: %s", err)
+	}
+	mappings, _ := Parse([]byte(`{"request": {"urlPath": "/report"}, "response": {"bodyFileName": "report.csv", "headers": {"Content-Type": "text/csv"}}}`))
+	mockApi, err := ToMockApi(mappings, "report", filesDir)
+	assert.Nil(t, err)
+	assert.Equal(t, common.BodyTypeText, mockApi.Responses.Get.BodyType)
+	assert.Equal(t, "a,b\n1,2\n", mockApi.Responses.Get.Body)
+	assert.Equal(t, "text/csv", mockApi.Responses.Get.ContentType())
+
+	mappings, _ = Parse([]byte(`{"request": {"urlPath": "/report"}, "response": {"bodyFileName": "missing.csv"}}`))
+	_, err = ToMockApi(mappings, "report", filesDir)
+	assert.ErrorContains(t, err, "error while reading the body file")
+}
+
+func TestRegexToPattern(t *testing.T) {
+	for regex, expected := range map[string]string{
+		"/users/[^/]+/orders": "users/*/orders",
+		"^/static/.*$":        "static/**",
+		"/files/(.+)":         "files/*",
+		"/v[12]/users":        "{p1:v[12]}/users",
+	} {
+		pattern, err := regexToPattern(regex)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, pattern, regex)
+	}
+}
+
+func TestFromMockApis(t *testing.T) {
+	mappings, _ := Parse([]byte(stubs))
+	mockApi, err := ToMockApi(mappings, "orders", "")
+	assert.Nil(t, err)
+
+	exported := FromMockApis([]*common.MockApi{mockApi})
+	assert.Equal(t, 3, len(exported.Mappings))
+
+	candidate := exported.Mappings[0]
+	assert.Equal(t, "GET", candidate.Request.Method)
+	assert.Equal(t, "^/orders/[0-9]+$", candidate.Request.URLPathPattern)
+	assert.Equal(t, 1, candidate.Priority)
+	assert.True(t, candidate.Request.Headers["Authorization"].Absent)
+	assert.Equal(t, "unauthorized", *candidate.Response.Body)
+
+	def := exported.Mappings[1]
+	assert.Equal(t, 2, def.Priority)
+	assert.Equal(t, map[string]interface{}{"id": float64(1)}, def.Response.JsonBody)
+	assert.Equal(t, 100, def.Response.FixedDelayMilliseconds)
+
+	post := exported.Mappings[2]
+	assert.Equal(t, "POST", post.Request.Method)
+	assert.Equal(t, "[0-9]+", post.Request.BodyPatterns[0].MatchesJsonPath.(JsonPathPattern).Matches)
+	assert.Equal(t, "checkout", post.ScenarioName)
+	assert.Equal(t, "paid", post.NewScenarioState)
+
+	// the exported mappings are imported back into the same MockApi
+	imported, err := ToMockApi(exported.Mappings, "orders", "")
+	assert.Nil(t, err)
+	assert.Equal(t, mockApi.Name, imported.Name)
+	assert.Equal(t, mockApi.URL, imported.URL)
+	assert.Equal(t, mockApi.Responses.Get.Candidates[0].Match, imported.Responses.Get.Candidates[0].Match)
+
+	// the mappings of several mockApis are imported back into a MockApi each
+	users := &common.MockApi{Name: "users", URL: "users", Responses: common.Response{Get: &common.MethodResponse{ResponseDef: common.ResponseDef{Status: 204}}}}
+	exported = FromMockApis([]*common.MockApi{mockApi, users})
+	mockApis, err := ToMockApis(exported.Mappings, "mappings", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(mockApis))
+	assert.Equal(t, mockApi.Name, mockApis[0].Name)
+	assert.Equal(t, mockApi.URL, mockApis[0].URL)
+	assert.Equal(t, mockApi.Responses.Get.Candidates[0].Match, mockApis[0].Responses.Get.Candidates[0].Match)
+	assert.Equal(t, users.Name, mockApis[1].Name)
+	assert.Equal(t, users.URL, mockApis[1].URL)
+	assert.Equal(t, 204, mockApis[1].Responses.Get.StatusCode())
+
+	// urls with plain parameters are exported as templates
+	exported = FromMockApis([]*common.MockApi{{Name: "user", URL: "users/{id}", Responses: common.Response{Get: &common.MethodResponse{ResponseDef: common.ResponseDef{Status: 204}}}}})
+	assert.Equal(t, "/users/{id}", exported.Mappings[0].Request.URLPathTemplate)
+}
@@ -1,7 +1,7 @@
 import { initialMockApiJsonString } from "./editor.model"
 
 export interface  IResourceObject {
-    id : number
+    id : string
     type : string
     data: IMockApi
 }
@@ -34,8 +34,14 @@ interface IModifiedResponse {
     post?: string
 }
 
+export interface  IValidationError {
+    field: string
+    rule: string
+    message: string
+}
+
 export class ResourceObject implements IResourceObject {
-    id = -1
+    id = ""
     type = "mockApi"
     data = JSON.parse(initialMockApiJsonString)
   }
\ No newline at end of file
@@ -1,6 +1,6 @@
 import { HttpClient, HttpErrorResponse } from "@angular/common/http";
 import { Injectable } from "@angular/core";
-import { IMockApi, ResourceObject } from "@models/mockApi.model";
+import { IMockApi, IValidationError, ResourceObject } from "@models/mockApi.model";
 import { BehaviorSubject, map, Observable, tap, throwError } from "rxjs";
 
 @Injectable()
@@ -9,6 +9,7 @@ export class MockApiService {
     private MOCK_API_SERVE_URL_BASE = 'http://localhost:8150/dynamocker/api';
     private MOCK_APIS = '/mock-apis';
     private MOCK_API = '/mock-api';
+    private VALIDATE = '/validate';
 
     private newMockApiSub: BehaviorSubject<ResourceObject> = new BehaviorSubject<ResourceObject>(new ResourceObject())
     private refreshListSub: BehaviorSubject<null> = new BehaviorSubject<null>(null)
@@ -36,7 +37,7 @@ export class MockApiService {
         return this.httpClient.get<ResourceObject[]>(url)
     }
 
-    deleteMockApi(mockApiUuid: number): Observable<null> {
+    deleteMockApi(mockApiUuid: string): Observable<null> {
         let url = this.MOCK_API_SERVE_URL_BASE + this.MOCK_API + "/" + mockApiUuid
         return this.httpClient.delete<null>(url)
     }
@@ -51,6 +52,11 @@ export class MockApiService {
         return this.httpClient.post<null>(url, JSON.stringify(mockApi))
     }
 
+    validateMockApi(mockApi : IMockApi) : Observable<IValidationError[]> {
+        let url = this.MOCK_API_SERVE_URL_BASE + this.MOCK_API + this.VALIDATE
+        return this.httpClient.post<IValidationError[]>(url, JSON.stringify(mockApi))
+    }
+
     putMockApi(resObj: ResourceObject): Observable<null> {
         var resList: ResourceObject[] = [];
         this.getAllMockApis().subscribe({